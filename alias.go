@@ -0,0 +1,42 @@
+package env
+
+import "log/slog"
+
+// lookupField reads the variable of the field bound to {t}, its keys prefixed
+// by {prefix} : the key then its aliases by order of precedence, each with its
+// `_FILE` variant unless `nofile`. Deprecated aliases are reported when used.
+func (o *options) lookupField(t tag, prefix string) (string, Origin, error) {
+	key := prefix + t.key
+	raw, origin, err := o.lookupSource(o.source, key, !t.noFile)
+	for _, a := range t.aliases {
+		if err != nil || origin.Kind != OriginUnset {
+			break
+		}
+		old := prefix + a.key
+		raw, origin, err = o.lookupSource(o.source, old, !t.noFile)
+		if a.deprecated && (err != nil || origin.Kind != OriginUnset) {
+			o.deprecated(old, key)
+		}
+	}
+	return raw, origin, err
+}
+
+// locateField returns where lookupField reads the variable bound to the
+// {keys} of a field from, without reading files
+func (o *options) locateField(keys []string, files bool) Origin {
+	for _, key := range keys {
+		if origin, _ := o.locate(o.source, key, files); origin.Kind != OriginUnset {
+			return origin
+		}
+	}
+	return Origin{}
+}
+
+// deprecated reports that the deprecated alias {old} of {key} is used
+func (o *options) deprecated(old, key string) {
+	if o.onDeprecated != nil {
+		o.onDeprecated(old, key)
+		return
+	}
+	slog.Warn("deprecated environment variable", "key", old, "replacement", key)
+}
@@ -0,0 +1,205 @@
+package env_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+type aliasConfig struct {
+	Password string `env:"DB_PASSWORD,required,alias=DATABASE_PASSWORD,deprecated=DB_PASS|DBPASS"`
+	Host     string `env:"DB_HOST,nofile,deprecated=DBHOST"`
+}
+
+func TestReadStruct_Aliases(t *testing.T) {
+	t.Parallel()
+
+	files := fstest.MapFS{
+		"run/secrets/new": {Data: []byte("new-file")},
+		"run/secrets/old": {Data: []byte("old-file")},
+	}
+
+	type deprecation struct{ old, key string }
+
+	tt := []struct {
+		name       string
+		vars       map[string]string
+		expected   aliasConfig
+		deprecated []deprecation
+	}{
+		{
+			name: "key wins",
+			vars: map[string]string{
+				"DB_PASSWORD":       "new",
+				"DATABASE_PASSWORD": "alias",
+				"DB_PASS":           "old",
+			},
+			expected: aliasConfig{Password: "new"},
+		},
+		{
+			name: "key file wins over aliases",
+			vars: map[string]string{
+				"DB_PASSWORD_FILE": "/run/secrets/new",
+				"DB_PASS":          "old",
+			},
+			expected: aliasConfig{Password: "new-file"},
+		},
+		{
+			name: "alias",
+			vars: map[string]string{
+				"DATABASE_PASSWORD": "alias",
+				"DB_PASS":           "old",
+			},
+			expected: aliasConfig{Password: "alias"},
+		},
+		{
+			name:       "deprecated",
+			vars:       map[string]string{"DB_PASS": "old", "DBPASS": "older", "DBHOST": "db.local"},
+			expected:   aliasConfig{Password: "old", Host: "db.local"},
+			deprecated: []deprecation{{"DB_PASS", "DB_PASSWORD"}, {"DBHOST", "DB_HOST"}},
+		},
+		{
+			name:       "deprecated file",
+			vars:       map[string]string{"DBPASS_FILE": "/run/secrets/old", "DBHOST_FILE": "/run/secrets/old"},
+			expected:   aliasConfig{Password: "old-file"},
+			deprecated: []deprecation{{"DBPASS", "DB_PASSWORD"}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				cfg        aliasConfig
+				deprecated []deprecation
+			)
+			err := env.ReadStruct(&cfg,
+				env.WithSource(env.MapSource(tc.vars, files)),
+				env.WithDeprecationHook(func(old, key string) {
+					deprecated = append(deprecated, deprecation{old, key})
+				}),
+			)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, cfg)
+			require.Equal(t, tc.deprecated, deprecated)
+		})
+	}
+}
+
+func TestReadStruct_AliasesPrefix(t *testing.T) {
+	t.Parallel()
+
+	src := env.MapSource(map[string]string{"APP_DB_PASS": "old"}, nil)
+
+	var (
+		cfg         aliasConfig
+		explained   []env.Explanation
+		old, newKey string
+	)
+	err := env.ReadStructPrefix("APP_", &cfg,
+		env.WithSource(src),
+		env.WithExplain(&explained),
+		env.WithDeprecationHook(func(o, k string) { old, newKey = o, k }),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "old", cfg.Password)
+	require.Equal(t, "APP_DB_PASS", old)
+	require.Equal(t, "APP_DB_PASSWORD", newKey)
+	require.Equal(t, env.Explanation{
+		Field:  "Password",
+		Key:    "APP_DB_PASSWORD",
+		Origin: env.Origin{Kind: env.OriginEnv, Key: "APP_DB_PASS"},
+	}, explained[0])
+
+	err = env.ReadStruct(&cfg, env.WithSource(src))
+	require.EqualError(t, err, `field "Password" (DB_PASSWORD): field is required`)
+}
+
+func TestReadStruct_AliasesExplain(t *testing.T) {
+	t.Parallel()
+
+	src := env.MapSource(map[string]string{
+		"DATABASE_PASSWORD": "alias",
+		"DB_PASS_FILE":      "/run/secrets/old",
+	}, nil)
+
+	var (
+		cfg       aliasConfig
+		explained []env.Explanation
+	)
+	require.NoError(t, env.ReadStruct(&cfg, env.WithSource(src), env.WithExplain(&explained)))
+	require.Equal(t, env.Explanation{
+		Field:    "Password",
+		Key:      "DB_PASSWORD",
+		Origin:   env.Origin{Kind: env.OriginEnv, Key: "DATABASE_PASSWORD"},
+		Shadowed: []env.Origin{{Kind: env.OriginFile, Key: "DB_PASS_FILE", Path: "/run/secrets/old"}},
+	}, explained[0])
+}
+
+func TestParseTag_Aliases(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Empty string `env:"EMPTY,alias=A||B"`
+	}
+	var cfg config
+	err := env.ReadStruct(&cfg, env.WithSource(env.MapSource(nil, nil)))
+	require.ErrorIs(t, err, env.ErrFieldTag)
+	require.ErrorContains(t, err, "missing alias key")
+}
+
+func TestDescribe_Aliases(t *testing.T) {
+	t.Parallel()
+
+	vars, err := env.Describe(&aliasConfig{}, env.WithPrefix("APP_"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"APP_DATABASE_PASSWORD", "APP_DB_PASS", "APP_DBPASS"}, vars[0].Aliases)
+	require.Equal(t, []string{"APP_DB_PASS", "APP_DBPASS"}, vars[0].Deprecated)
+
+	var buf bytes.Buffer
+	require.NoError(t, env.Usage(&buf, &aliasConfig{}))
+	require.Equal(t, ""+
+		"VARIABLE     TYPE    DEFAULT   DESCRIPTION\n"+
+		"DB_PASSWORD  string  required  (or DB_PASSWORD_FILE, alias DATABASE_PASSWORD, deprecated DB_PASS, deprecated DBPASS)\n"+
+		"DB_HOST      string            (deprecated DBHOST)\n",
+		buf.String())
+}
+
+func TestSchema_Aliases(t *testing.T) {
+	t.Parallel()
+
+	raw, err := env.Schema(&aliasConfig{})
+	require.NoError(t, err)
+
+	var doc struct {
+		Properties map[string]struct {
+			Aliases    []string `json:"x-env-aliases"`
+			Deprecated []string `json:"x-env-deprecated"`
+		} `json:"properties"`
+		AllOf []struct {
+			AnyOf []struct {
+				Required []string `json:"required"`
+			} `json:"anyOf"`
+		} `json:"allOf"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	require.Equal(t, []string{"DATABASE_PASSWORD", "DB_PASS", "DBPASS"}, doc.Properties["DB_PASSWORD"].Aliases)
+	require.Equal(t, []string{"DBHOST"}, doc.Properties["DB_HOST"].Deprecated)
+
+	require.Len(t, doc.AllOf, 1)
+	var alternatives []string
+	for _, alt := range doc.AllOf[0].AnyOf {
+		alternatives = append(alternatives, alt.Required...)
+	}
+	require.Equal(t, []string{
+		"DB_PASSWORD", "DB_PASSWORD_FILE",
+		"DATABASE_PASSWORD", "DATABASE_PASSWORD_FILE",
+		"DB_PASS", "DB_PASS_FILE",
+		"DBPASS", "DBPASS_FILE",
+	}, alternatives)
+}
@@ -0,0 +1,419 @@
+// Command envcheck validates the environment against a JSON Schema exported
+// with env.Schema, without compiling the service it describes.
+//
+// Usage:
+//
+//	envcheck [-json] [-prefix APP_] [-trim] schema.json
+//
+// Variables are resolved like env.ReadStruct resolves them, `_FILE` variants
+// and aliases included. Every missing, malformed or unknown variable is
+// reported, the command exits with status 1 when there is at least one problem
+// and 2 when the schema cannot be read.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xdrm-io/env"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], env.OSSource(), os.Environ(), os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command against {src}. {environ} lists the variables of
+// {src} as "KEY=value" for unknown variables detection.
+func run(args []string, src env.Source, environ []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("envcheck", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var (
+		asJSON = flags.Bool("json", false, "write the report as JSON")
+		prefix = flags.String("prefix", "", "report unknown variables starting with `prefix`")
+		trim   = flags.Bool("trim", false, "trim trailing whitespace of secret files")
+	)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: envcheck [-json] [-prefix APP_] [-trim] schema.json")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	s, err := readSchema(flags.Arg(0), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "envcheck: %v\n", err)
+		return 2
+	}
+
+	c := checker{source: src, trim: *trim}
+	problems := c.check(s)
+	if *prefix != "" {
+		problems = append(problems, unknown(s, *prefix, environ)...)
+	}
+
+	if *asJSON {
+		err = writeJSON(stdout, problems)
+	} else {
+		err = writeText(stdout, problems)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "envcheck: %v\n", err)
+		return 2
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// schema is the subset of the JSON Schema keywords produced by env.Schema
+type schema struct {
+	Properties map[string]property `json:"properties"`
+	Required   []string            `json:"required"`
+	AllOf      []struct {
+		AnyOf []struct {
+			Required []string `json:"required"`
+		} `json:"anyOf"`
+	} `json:"allOf"`
+}
+
+type property struct {
+	Enum      []string `json:"enum"`
+	Pattern   string   `json:"pattern"`
+	Format    string   `json:"format"`
+	MinLength *int     `json:"minLength"`
+	MaxLength *int     `json:"maxLength"`
+	GoType    string   `json:"x-go-type"`
+	FileKey   string   `json:"x-env-file"`
+	Expand    bool     `json:"x-env-expand"`
+	Aliases   []string `json:"x-env-aliases"`
+	XMinimum  string   `json:"x-minimum"`
+	XMaximum  string   `json:"x-maximum"`
+}
+
+// readSchema reads the schema at {path}, "-" reads {stdin}
+func readSchema(path string, stdin io.Reader) (*schema, error) {
+	var (
+		raw []byte
+		err error
+	)
+	if path == "-" {
+		raw, err = io.ReadAll(stdin)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &s, nil
+}
+
+// Problem kinds
+const (
+	kindMissing = "missing"
+	kindInvalid = "invalid"
+	kindUnknown = "unknown"
+)
+
+// problem is a variable that does not comply with the schema
+type problem struct {
+	Key     string `json:"key"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// checker resolves the variables of a schema from a source
+type checker struct {
+	source env.Source
+	trim   bool
+	// set are the keys resolved to a value
+	set map[string]bool
+}
+
+// check returns the problems of every property of {s}, sorted by key
+func (c *checker) check(s *schema) []problem {
+	c.set = make(map[string]bool, len(s.Properties))
+
+	var problems []problem
+	for _, key := range sortedKeys(s.Properties) {
+		p := s.Properties[key]
+		raw, set, err := c.lookup(key, p)
+		if err != nil {
+			problems = append(problems, problem{Key: key, Kind: kindInvalid, Message: err.Error()})
+			continue
+		}
+		if !set {
+			continue
+		}
+		c.set[key] = true
+		if p.Expand {
+			raw, err = env.Expand(raw,
+				env.WithSource(c.source),
+				env.WithFilePolicy(env.FilePolicy{TrimSpace: c.trim}),
+			)
+			if err != nil {
+				problems = append(problems, problem{Key: key, Kind: kindInvalid, Message: err.Error()})
+				continue
+			}
+		}
+		if err := p.validate(raw); err != nil {
+			problems = append(problems, problem{Key: key, Kind: kindInvalid, Message: err.Error()})
+		}
+	}
+
+	for _, key := range s.Required {
+		if !c.present(key) {
+			problems = append(problems, problem{Key: key, Kind: kindMissing, Message: "required variable is not set"})
+		}
+	}
+	for _, group := range s.AllOf {
+		satisfied := false
+		var alternatives []string
+		for _, alt := range group.AnyOf {
+			satisfied = satisfied || len(alt.Required) > 0 && !slices.ContainsFunc(alt.Required, func(k string) bool { return !c.present(k) })
+			alternatives = append(alternatives, strings.Join(alt.Required, " and "))
+		}
+		if satisfied || len(alternatives) == 0 {
+			continue
+		}
+		problems = append(problems, problem{
+			Key:     group.AnyOf[0].Required[0],
+			Kind:    kindMissing,
+			Message: "required variable is not set, set one of " + strings.Join(alternatives, ", "),
+		})
+	}
+	return problems
+}
+
+// lookup resolves the variable {key} like env.ReadStruct, then its aliases,
+// `_FILE` variants included when the property accepts them
+func (c *checker) lookup(key string, p property) (string, bool, error) {
+	for _, k := range append([]string{key}, p.Aliases...) {
+		raw, ok, err := c.lookupKey(k, p.fileSuffix(key))
+		if err != nil || ok {
+			return raw, ok, err
+		}
+	}
+	return "", false, nil
+}
+
+// lookupKey resolves the variable {key} like env.Read, the variable suffixed
+// by {suffix} included when not empty
+func (c *checker) lookupKey(key, suffix string) (string, bool, error) {
+	if suffix == "" {
+		raw, ok := c.source.Lookup(key)
+		return raw, ok, nil
+	}
+	raw, origin, err := env.Lookup(key,
+		env.WithSource(c.source),
+		env.WithFilePolicy(env.FilePolicy{Suffix: suffix, TrimSpace: c.trim}),
+	)
+	return raw, origin.Kind != env.OriginUnset, err
+}
+
+// fileSuffix returns the suffix of the `_FILE` variant of the property {key},
+// empty when it accepts none
+func (p property) fileSuffix(key string) string {
+	if p.FileKey == "" || !strings.HasPrefix(p.FileKey, key) {
+		return ""
+	}
+	return strings.TrimPrefix(p.FileKey, key)
+}
+
+// present returns whether {key} is set, either as a resolved property or as a
+// raw variable, e.g. a `_FILE` variant
+func (c *checker) present(key string) bool {
+	if c.set[key] {
+		return true
+	}
+	_, ok := c.source.Lookup(key)
+	return ok
+}
+
+// validate checks {raw} against the keywords of the property. Values are
+// never part of the error, they may be secrets.
+func (p property) validate(raw string) error {
+	if check, ok := typeChecks[p.GoType]; ok {
+		if err := check(raw); err != nil {
+			return fmt.Errorf("not a valid %s", p.GoType)
+		}
+	}
+	if p.Enum != nil && !slices.Contains(p.Enum, raw) {
+		return fmt.Errorf("must be one of %s", strings.Join(p.Enum, ", "))
+	}
+	if p.Pattern != "" {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema pattern: %w", err)
+		}
+		if !re.MatchString(raw) {
+			return fmt.Errorf("must match %s", p.Pattern)
+		}
+	}
+	if p.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, raw); err != nil {
+			return errors.New("must be an RFC3339 date-time")
+		}
+	}
+	if n := utf8.RuneCountInString(raw); p.MinLength != nil && n < *p.MinLength {
+		return fmt.Errorf("must be at least %d characters long", *p.MinLength)
+	}
+	if n := utf8.RuneCountInString(raw); p.MaxLength != nil && n > *p.MaxLength {
+		return fmt.Errorf("must be at most %d characters long", *p.MaxLength)
+	}
+	if p.XMinimum == "" && p.XMaximum == "" {
+		return nil
+	}
+	if p.GoType == "time.Duration" {
+		return validateDuration(raw, p.XMinimum, p.XMaximum)
+	}
+	return validateNumber(raw, p.XMinimum, p.XMaximum)
+}
+
+// validateNumber checks the numeric range of {raw}
+func validateNumber(raw, min, max string) error {
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return errors.New("must be a number")
+	}
+	if bound, err := strconv.ParseFloat(min, 64); err == nil && v < bound {
+		return fmt.Errorf("must be at least %s", min)
+	}
+	if bound, err := strconv.ParseFloat(max, 64); err == nil && v > bound {
+		return fmt.Errorf("must be at most %s", max)
+	}
+	return nil
+}
+
+// validateDuration checks the duration range of {raw}
+func validateDuration(raw, min, max string) error {
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		return errors.New("must be a duration")
+	}
+	if bound, err := time.ParseDuration(min); err == nil && v < bound {
+		return fmt.Errorf("must be at least %s", min)
+	}
+	if bound, err := time.ParseDuration(max); err == nil && v > bound {
+		return fmt.Errorf("must be at most %s", max)
+	}
+	return nil
+}
+
+// typeChecks decode raw values of the "x-go-type" of properties with the
+// decoders of ReadStruct. Other types are checked by the schema keywords only.
+var typeChecks = map[string]func(raw string) error{
+	"int":           decode[int],
+	"int8":          decode[int8],
+	"int16":         decode[int16],
+	"int32":         decode[int32],
+	"int64":         decode[int64],
+	"uint":          decode[uint],
+	"uint8":         decode[uint8],
+	"uint16":        decode[uint16],
+	"uint32":        decode[uint32],
+	"uint64":        decode[uint64],
+	"float32":       decode[float32],
+	"float64":       decode[float64],
+	"bool":          decode[bool],
+	"time.Duration": decode[time.Duration],
+	"time.Time":     decode[time.Time],
+	"slog.Level":    decode[slog.Level],
+}
+
+// decode decodes {raw} into a T
+func decode[T any](raw string) error {
+	const key = "VALUE"
+	src := env.MapSource(map[string]string{key: raw}, nil)
+	_, err := env.Get[T](key, env.WithSource(src))
+	return err
+}
+
+// unknown returns the variables of {environ} starting with {prefix} that are
+// neither a property nor the `_FILE` variant of a property
+func unknown(s *schema, prefix string, environ []string) []problem {
+	known := make(map[string]bool, 2*len(s.Properties))
+	for key, p := range s.Properties {
+		known[key] = true
+		if p.FileKey != "" {
+			known[p.FileKey] = true
+		}
+		for _, alias := range p.Aliases {
+			known[alias] = true
+			if suffix := p.fileSuffix(key); suffix != "" {
+				known[alias+suffix] = true
+			}
+		}
+	}
+
+	var keys []string
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(key, prefix) && !known[key] {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	problems := make([]problem, 0, len(keys))
+	for _, key := range slices.Compact(keys) {
+		problems = append(problems, problem{Key: key, Kind: kindUnknown, Message: "variable is not part of the schema"})
+	}
+	return problems
+}
+
+func writeText(w io.Writer, problems []problem) error {
+	for _, p := range problems {
+		if _, err := fmt.Fprintf(w, "%s: %s: %s\n", p.Key, p.Kind, p.Message); err != nil {
+			return err
+		}
+	}
+	if len(problems) == 0 {
+		_, err := fmt.Fprintln(w, "ok")
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d problem(s)\n", len(problems))
+	return err
+}
+
+func writeJSON(w io.Writer, problems []problem) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		OK       bool      `json:"ok"`
+		Problems []problem `json:"problems"`
+	}{
+		OK:       len(problems) == 0,
+		Problems: append([]problem{}, problems...),
+	})
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
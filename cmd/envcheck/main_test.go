@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+type config struct {
+	Host     string        `env:"APP_HOST,required,deprecated=APP_HOSTNAME"`
+	Password string        `env:"APP_PASSWORD,required,secret"`
+	Port     int           `env:"APP_PORT,default=8080,min=1,max=65535,nofile"`
+	Mode     string        `env:"APP_MODE,oneof=dev|prod"`
+	Timeout  time.Duration `env:"APP_TIMEOUT,min=1s"`
+	Workers  int           `env:"APP_WORKERS,expand,max=64"`
+}
+
+func writeSchema(t *testing.T) string {
+	t.Helper()
+	raw, err := env.Schema(&config{})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+	return path
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	files := fstest.MapFS{"run/secrets/password": {Data: []byte("secret")}}
+
+	tt := []struct {
+		name     string
+		args     []string
+		vars     map[string]string
+		code     int
+		expected string
+	}{
+		{
+			name:     "ok",
+			vars:     map[string]string{"APP_HOST": "localhost", "APP_PASSWORD_FILE": "/run/secrets/password", "APP_WORKERS": "${APP_CPUS:-4}"},
+			code:     0,
+			expected: "ok\n",
+		},
+		{
+			name:     "alias",
+			args:     []string{"-prefix", "APP_"},
+			vars:     map[string]string{"APP_HOSTNAME_FILE": "/run/secrets/password", "APP_PASSWORD": "secret", "APP_PORT": "80"},
+			code:     0,
+			expected: "ok\n",
+		},
+		{
+			name:     "expanded",
+			vars:     map[string]string{"APP_HOST": "localhost", "APP_PASSWORD": "secret", "APP_CPUS": "8", "APP_WORKERS": "${APP_CPUS}0"},
+			code:     1,
+			expected: "APP_WORKERS: invalid: must be at most 64\n1 problem(s)\n",
+		},
+		{
+			name:     "expand syntax",
+			vars:     map[string]string{"APP_HOST": "localhost", "APP_PASSWORD": "secret", "APP_WORKERS": "${APP_CPUS"},
+			code:     1,
+			expected: "APP_WORKERS: invalid: invalid variable reference: unterminated ${\n1 problem(s)\n",
+		},
+		{
+			name: "missing",
+			vars: map[string]string{"APP_PORT": "80"},
+			code: 1,
+			expected: "APP_HOST: missing: required variable is not set, set one of APP_HOST, APP_HOST_FILE, APP_HOSTNAME, APP_HOSTNAME_FILE\n" +
+				"APP_PASSWORD: missing: required variable is not set, set one of APP_PASSWORD, APP_PASSWORD_FILE\n" +
+				"2 problem(s)\n",
+		},
+		{
+			name: "malformed",
+			vars: map[string]string{
+				"APP_HOST":          "localhost",
+				"APP_PASSWORD_FILE": "/run/secrets/missing",
+				"APP_PORT":          "99999",
+				"APP_MODE":          "prodd",
+				"APP_TIMEOUT":       "10ms",
+			},
+			code: 1,
+			expected: "APP_MODE: invalid: must be one of dev, prod\n" +
+				"APP_PASSWORD: invalid: APP_PASSWORD_FILE: file not found: open run/secrets/missing: file does not exist\n" +
+				"APP_PORT: invalid: must be at most 65535\n" +
+				"APP_TIMEOUT: invalid: must be at least 1s\n" +
+				"4 problem(s)\n",
+		},
+		{
+			name:     "type",
+			vars:     map[string]string{"APP_HOST": "localhost", "APP_PASSWORD": "secret", "APP_PORT": "http"},
+			code:     1,
+			expected: "APP_PORT: invalid: not a valid int\n1 problem(s)\n",
+		},
+		{
+			name: "unknown",
+			args: []string{"-prefix", "APP_"},
+			vars: map[string]string{
+				"APP_HOST":      "localhost",
+				"APP_PASSWORD":  "secret",
+				"APP_PORT_FILE": "/run/secrets/port",
+				"APP_DEBUG":     "1",
+				"HOME":          "/root",
+			},
+			code: 1,
+			expected: "APP_DEBUG: unknown: variable is not part of the schema\n" +
+				"APP_PORT_FILE: unknown: variable is not part of the schema\n" +
+				"2 problem(s)\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var environ []string
+			for k, v := range tc.vars {
+				environ = append(environ, k+"="+v)
+			}
+			var stdout, stderr bytes.Buffer
+			args := append(tc.args, writeSchema(t))
+			code := run(args, env.MapSource(tc.vars, files), environ, nil, &stdout, &stderr)
+			require.Equal(t, tc.code, code, stderr.String())
+			require.Equal(t, tc.expected, stdout.String())
+		})
+	}
+}
+
+func TestRun_JSON(t *testing.T) {
+	t.Parallel()
+
+	raw, err := env.Schema(&config{})
+	require.NoError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	src := env.MapSource(map[string]string{"APP_HOST": "localhost"}, nil)
+	code := run([]string{"-json", "-"}, src, nil, bytes.NewReader(raw), &stdout, &stderr)
+	require.Equal(t, 1, code, stderr.String())
+
+	var report struct {
+		OK       bool      `json:"ok"`
+		Problems []problem `json:"problems"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+	require.False(t, report.OK)
+	require.Equal(t, []problem{{
+		Key:     "APP_PASSWORD",
+		Kind:    kindMissing,
+		Message: "required variable is not set, set one of APP_PASSWORD, APP_PASSWORD_FILE",
+	}}, report.Problems)
+}
+
+func TestRun_Usage(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 2, run(nil, env.MapSource(nil, nil), nil, nil, &stdout, &stderr))
+	require.Equal(t, 2, run([]string{"missing.json"}, env.MapSource(nil, nil), nil, nil, &stdout, &stderr))
+	require.Empty(t, stdout.String())
+}
@@ -0,0 +1,553 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xdrm-io/env"
+)
+
+// generate returns the source of the loaders of the struct {types} declared
+// in the package at {dir}. The file {output} is not parsed, it is the
+// previously generated one.
+func generate(dir string, types []string, output string) ([]byte, error) {
+	pkg, err := parsePackage(dir, output)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: pkg}
+	var body bytes.Buffer
+	for _, name := range types {
+		if err := g.loader(&body, name); err != nil {
+			return nil, err
+		}
+	}
+	return g.file(body.Bytes())
+}
+
+// pkg is a parsed package
+type pkg struct {
+	fset    *token.FileSet
+	name    string
+	structs map[string]structDecl
+}
+
+// structDecl is a struct type along with the imports of its file, by name
+type structDecl struct {
+	typ     *ast.StructType
+	imports map[string]string
+}
+
+// parsePackage parses the non-test Go files of {dir}, except {output}
+func parsePackage(dir, output string) (*pkg, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pkg{fset: token.NewFileSet(), structs: map[string]structDecl{}}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == output {
+			continue
+		}
+		file, err := parser.ParseFile(p.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if p.name == "" {
+			p.name = file.Name.Name
+		}
+		if file.Name.Name != p.name {
+			continue
+		}
+
+		imports := map[string]string{}
+		for _, imp := range file.Imports {
+			importPath, _ := strconv.Unquote(imp.Path.Value)
+			name := path.Base(importPath)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			imports[name] = importPath
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.TypeSpec)
+				if st, ok := spec.Type.(*ast.StructType); ok && spec.TypeParams == nil {
+					p.structs[spec.Name.Name] = structDecl{typ: st, imports: imports}
+				}
+			}
+		}
+	}
+	if p.name == "" {
+		return nil, fmt.Errorf("no Go file in %s", dir)
+	}
+	return p, nil
+}
+
+// generator writes the loaders of a package
+type generator struct {
+	pkg *pkg
+	// parsers are the closures used by the current loader, by name
+	parsers map[string]scalar
+	// nested counts the nested struct pointers of the current loader
+	nested int
+	// fields counts the fields of the current loader
+	fields int
+}
+
+// walk is the position of the struct being generated
+type walk struct {
+	// target is the expression of the struct, e.g. "c.DB"
+	target string
+	// prefix is prepended to every key
+	prefix string
+	// path is prepended to every field name, e.g. "DB."
+	path string
+	// set is the variable marking the innermost nested struct pointer as set
+	set string
+	// parents are the struct types being generated
+	parents []string
+}
+
+const loaderHeader = `
+// LoadFromEnv fills the fields of c from the environment like env.ReadStruct,
+// without reflection. Only the WithSource and WithFilePolicy options apply.
+func (c *%s) LoadFromEnv(opts ...env.Option) error {
+	var errs env.Errors
+	fail := func(field, key string, err error) {
+		errs = append(errs, &env.FieldError{Field: field, Key: key, Err: err})
+	}
+	lookup := func(set *bool, key, def string, hasDefault, required bool) (string, bool, error) {
+		raw, origin, err := env.Lookup(key, opts...)
+		if set != nil && origin.Kind != env.OriginUnset {
+			*set = true
+		}
+		switch {
+		case err != nil:
+			return "", false, fmt.Errorf("%%w: %%w", env.ErrFieldFile, err)
+		case origin.Kind != env.OriginUnset:
+			return raw, true, nil
+		case required:
+			return "", false, env.ErrFieldRequired
+		}
+		return def, hasDefault, nil
+	}
+`
+
+const loaderFooter = `
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+`
+
+// loader writes the LoadFromEnv method of the struct type {name}
+func (g *generator) loader(w *bytes.Buffer, name string) error {
+	decl, ok := g.pkg.structs[name]
+	if !ok {
+		return fmt.Errorf("struct type %s not found in package %s", name, g.pkg.name)
+	}
+	g.parsers, g.nested, g.fields = map[string]scalar{}, 0, 0
+
+	var fields bytes.Buffer
+	if err := g.structFields(&fields, decl, walk{target: "c", parents: []string{name}}); err != nil {
+		return err
+	}
+	if g.fields == 0 {
+		return fmt.Errorf("struct type %s has no env field", name)
+	}
+
+	fmt.Fprintf(w, loaderHeader, name)
+	for _, parser := range sortedKeys(g.parsers) {
+		fmt.Fprintf(w, "%s := func(raw string) (%s, error) {\n%s\n}\n", parser, g.parsers[parser].name, g.parsers[parser].closure)
+	}
+	w.Write(fields.Bytes())
+	w.WriteString(loaderFooter)
+	return nil
+}
+
+// structFields writes the code filling the fields of {decl}, it returns the
+// errors of every invalid field
+func (g *generator) structFields(w *bytes.Buffer, decl structDecl, at walk) error {
+	var errs []error
+	for _, field := range decl.typ.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			raw, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(raw)
+		}
+
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{embeddedName(field.Type)}
+		}
+		for _, name := range names {
+			rawTag, tagged := tag.Lookup("env")
+			switch {
+			case !token.IsExported(name.Name):
+				// like env.ReadStruct, nested structs may hold private state
+				if at.path == "" {
+					errs = append(errs, g.fieldError(name, at, env.ErrFieldUnexported))
+				}
+			case !tagged:
+				errs = append(errs, g.nestedField(w, decl, field.Type, name, tag, at))
+			case rawTag != "":
+				if err := g.field(w, decl, field.Type, name.Name, rawTag, at); err != nil {
+					errs = append(errs, g.fieldError(name, at, err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fieldError returns the error of the field {name} with its position
+func (g *generator) fieldError(name *ast.Ident, at walk, err error) error {
+	return fmt.Errorf("%s: field %s: %w", g.pkg.fset.Position(name.Pos()), at.path+name.Name, err)
+}
+
+// field writes the code filling the field {name} of type {expr}
+func (g *generator) field(w *bytes.Buffer, decl structDecl, expr ast.Expr, name, rawTag string, at walk) error {
+	t, err := parseTag(rawTag)
+	if err != nil {
+		return err
+	}
+	ft, err := resolveType(expr, decl.imports)
+	if err != nil {
+		return err
+	}
+	if err := checkTag(rawTag, ft.reflectType()); err != nil {
+		return err
+	}
+
+	g.fields++
+	path, key := at.path+name, at.prefix+t.key
+	fmt.Fprintf(w, "\n// %s\n", path)
+	set := "nil"
+	if at.set != "" {
+		set = "&" + at.set
+	}
+	fmt.Fprintf(w, "if raw, ok, err := lookup(%s, %q, %q, %t, %t); err != nil {\n", set, key, t.def, t.hasDefault, t.required)
+	fmt.Fprintf(w, "fail(%q, %q, err)\n", path, key)
+	w.WriteString("} else if ok {\n")
+
+	assign := fmt.Sprintf("%s.%s = v\n", at.target, name)
+	if ft.pointer {
+		assign = fmt.Sprintf("%s.%s = &v\n", at.target, name)
+	}
+
+	switch {
+	case ft.kind == kindScalar && ft.elem.decode == "" && !ft.pointer:
+		fmt.Fprintf(w, "%s.%s = %s\n", at.target, name, ft.elem.convert("raw"))
+	case ft.kind == kindScalar && ft.elem.decode == "":
+		fmt.Fprintf(w, "v := %s\n%s", ft.elem.convert("raw"), assign)
+	default:
+		fmt.Fprintf(w, "if v, err := %s; err != nil {\n", g.decoder(ft, t))
+		fmt.Fprintf(w, "fail(%q, %q, fmt.Errorf(\"%%w: %%w\", env.ErrFieldDecode, err))\n", path, key)
+		fmt.Fprintf(w, "} else {\n%s}\n", assign)
+	}
+	w.WriteString("}\n")
+	return nil
+}
+
+// nestedField writes the code filling the untagged field {name} when it is a
+// struct, or a pointer to a struct, of the package. Like env.ReadStruct, other
+// structs are skipped unless they have an `envPrefix` tag or fields bound to
+// variables. Errors are positioned.
+func (g *generator) nestedField(w *bytes.Buffer, decl structDecl, expr ast.Expr, ident *ast.Ident, tag reflect.StructTag, at walk) error {
+	name := ident.Name
+	prefix, prefixed := tag.Lookup("envPrefix")
+	typ, pointer := expr, false
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ, pointer = star.X, true
+	}
+
+	var typeName string
+	switch typ := typ.(type) {
+	case *ast.Ident:
+		typeName = typ.Name
+	case *ast.SelectorExpr:
+		// types of other packages, e.g. a *slog.Logger, cannot be inspected
+		// and are only generated when they are explicitly prefixed
+		if _, err := resolveScalar(typ, decl.imports); err == nil || !prefixed {
+			return nil
+		}
+		return g.fieldError(ident, at, fmt.Errorf("nested type %s of another package is not supported", types.ExprString(typ)))
+	case *ast.StructType:
+		if !prefixed && !g.hasEnvFields(typ, nil) {
+			return nil
+		}
+		return g.fieldError(ident, at, errors.New("anonymous nested struct is not supported"))
+	default:
+		return nil
+	}
+	nested, ok := g.pkg.structs[typeName]
+	if !ok || !prefixed && !g.hasEnvFields(nested.typ, []string{typeName}) {
+		return nil
+	}
+
+	inner := walk{
+		target:  at.target + "." + name,
+		prefix:  at.prefix + prefix,
+		path:    at.path + name + ".",
+		set:     at.set,
+		parents: append(slices.Clip(at.parents), typeName),
+	}
+	if !pointer {
+		return g.structFields(w, nested, inner)
+	}
+	// a nil pointer to a parent type would recurse forever
+	if slices.Contains(at.parents, typeName) {
+		return nil
+	}
+
+	// nil pointers are only allocated when one of their fields has a value,
+	// the errors of their fields are dropped otherwise
+	g.nested++
+	n := g.nested
+	inner.target, inner.set = fmt.Sprintf("n%d", n), fmt.Sprintf("set%d", n)
+	fmt.Fprintf(w, "\n// %s\n{\n", at.path+name)
+	fmt.Fprintf(w, "%s := %s.%s\nif %s == nil {\n%s = new(%s)\n}\n%s, errs%d := false, len(errs)\n",
+		inner.target, at.target, name, inner.target, inner.target, typeName, inner.set, n)
+	if err := g.structFields(w, nested, inner); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "if %s {\n%s.%s = %s\n", inner.set, at.target, name, inner.target)
+	if at.set != "" {
+		fmt.Fprintf(w, "%s = true\n", at.set)
+	}
+	fmt.Fprintf(w, "} else {\nerrs = errs[:errs%d]\n}\n}\n", n)
+	return nil
+}
+
+// hasEnvFields returns whether the struct {typ}, or one of the nested structs
+// of the package, has exported fields with an `env` or `envPrefix` tag.
+// {parents} are the types being walked, to stop on self-referencing types.
+func (g *generator) hasEnvFields(typ *ast.StructType, parents []string) bool {
+	for _, field := range typ.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			raw, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(raw)
+		}
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{embeddedName(field.Type)}
+		}
+		if !slices.ContainsFunc(names, func(name *ast.Ident) bool { return token.IsExported(name.Name) }) {
+			continue
+		}
+		if _, ok := tag.Lookup("env"); ok {
+			return true
+		}
+		if _, ok := tag.Lookup("envPrefix"); ok {
+			return true
+		}
+
+		fieldType := field.Type
+		if star, ok := fieldType.(*ast.StarExpr); ok {
+			fieldType = star.X
+		}
+		switch t := fieldType.(type) {
+		case *ast.StructType:
+			if g.hasEnvFields(t, parents) {
+				return true
+			}
+		case *ast.Ident:
+			nested, ok := g.pkg.structs[t.Name]
+			if ok && !slices.Contains(parents, t.Name) && g.hasEnvFields(nested.typ, append(slices.Clip(parents), t.Name)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decoder returns the expression decoding the variable `raw` into a value of
+// type {ft} and an error
+func (g *generator) decoder(ft fieldType, t tag) string {
+	var b strings.Builder
+	switch ft.kind {
+	case kindScalar:
+		return g.decode(ft.elem, "raw")
+
+	case kindSlice, kindArray:
+		fmt.Fprintf(&b, "func() (%s, error) {\n", ft.goType())
+		fmt.Fprintf(&b, "items, err := env.SplitList(raw, %q)\nif err != nil {\nreturn %s, err\n}\n", t.separator(), ft.zero())
+		if ft.kind == kindArray {
+			fmt.Fprintf(&b, "var list %s\n", ft.goType())
+			fmt.Fprintf(&b, "if len(items) != %d {\nreturn list, fmt.Errorf(\"expected %d items, got %%d\", len(items))\n}\n", ft.length, ft.length)
+		} else {
+			fmt.Fprintf(&b, "list := make(%s, len(items))\n", ft.goType())
+		}
+		b.WriteString("for i, item := range items {\n")
+		if ft.elem.decode == "" {
+			fmt.Fprintf(&b, "list[i] = %s\n", ft.elem.convert("item"))
+		} else {
+			fmt.Fprintf(&b, "if list[i], err = %s; err != nil {\n", g.decode(ft.elem, "item"))
+			fmt.Fprintf(&b, "return %s, fmt.Errorf(\"item %%d: %%w\", i, err)\n}\n", ft.zero())
+		}
+		b.WriteString("}\nreturn list, nil\n}()")
+
+	case kindMap, kindSet:
+		fmt.Fprintf(&b, "func() (%s, error) {\n", ft.goType())
+		fmt.Fprintf(&b, "entries, err := env.SplitList(raw, %q)\nif err != nil {\nreturn nil, err\n}\n", t.separator())
+		fmt.Fprintf(&b, "m := make(%s, len(entries))\n", ft.goType())
+		b.WriteString("for _, entry := range entries {\n")
+		if ft.kind == kindSet {
+			b.WriteString("rawKey := entry\n")
+		} else {
+			fmt.Fprintf(&b, "rawKey, rawValue, ok := strings.Cut(entry, %q)\n", t.kvSeparator())
+			fmt.Fprintf(&b, "if !ok {\nreturn nil, fmt.Errorf(\"missing %%q in map entry %%q\", %q, entry)\n}\n", t.kvSeparator())
+		}
+		g.mapItem(&b, "key", ft.key)
+		if ft.kind == kindSet {
+			b.WriteString("m[key] = struct{}{}\n")
+		} else {
+			g.mapItem(&b, "value", ft.elem)
+			b.WriteString("m[key] = value\n")
+		}
+		b.WriteString("}\nreturn m, nil\n}()")
+	}
+	return b.String()
+}
+
+// mapItem writes the code decoding the map {item}, "key" or "value", from the
+// variable rawKey or rawValue
+func (g *generator) mapItem(b *strings.Builder, item string, s scalar) {
+	raw := "raw" + strings.ToUpper(item[:1]) + item[1:]
+	if s.decode == "" {
+		fmt.Fprintf(b, "%s := %s\n", item, s.convert("strings.TrimSpace("+raw+")"))
+		return
+	}
+	fmt.Fprintf(b, "%s, err := %s\n", item, g.decode(s, "strings.TrimSpace("+raw+")"))
+	fmt.Fprintf(b, "if err != nil {\nreturn nil, fmt.Errorf(\"map %s %%q: %%w\", %s, err)\n}\n", item, raw)
+}
+
+// decode returns the call decoding {arg} with the fallible scalar {s}
+func (g *generator) decode(s scalar, arg string) string {
+	if s.closure != "" {
+		g.parsers[s.decode] = s
+	}
+	return s.decode + "(" + arg + ")"
+}
+
+// file returns the formatted generated file made of {body}
+func (g *generator) file(body []byte) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by envgen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", g.pkg.name)
+
+	std, err := usedImports(body)
+	if err != nil {
+		return nil, err
+	}
+	b.WriteString("import (\n")
+	for _, imp := range std {
+		fmt.Fprintf(&b, "%q\n", imp)
+	}
+	fmt.Fprintf(&b, "\n%q\n)\n", "github.com/xdrm-io/env")
+	b.Write(body)
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated code: %w", err)
+	}
+	return src, nil
+}
+
+// stdImports are the standard packages the generated code may use, by name
+var stdImports = map[string]string{
+	"fmt":     "fmt",
+	"slog":    "log/slog",
+	"strconv": "strconv",
+	"strings": "strings",
+	"time":    "time",
+}
+
+// usedImports returns the sorted standard packages referenced by {body}
+func usedImports(body []byte) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", append([]byte("package p\n"), body...), parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated code: %w", err)
+	}
+
+	var imports []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok {
+			if imp, ok := stdImports[x.Name]; ok && !slices.Contains(imports, imp) {
+				imports = append(imports, imp)
+			}
+		}
+		return true
+	})
+	slices.Sort(imports)
+	return imports, nil
+}
+
+// embeddedName returns the field name of an embedded field of type {expr}
+func embeddedName(expr ast.Expr) *ast.Ident {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel
+	case *ast.Ident:
+		return t
+	}
+	return ast.NewIdent("?")
+}
+
+// checkTag validates {rawTag} for a field of type {t} like ReadStruct does,
+// default values included
+func checkTag(rawTag string, t reflect.Type) error {
+	v := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: "V",
+		Type: t,
+		Tag:  reflect.StructTag("env:" + strconv.Quote(rawTag)),
+	}}))
+	err := env.ReadStruct(v.Interface(), env.WithSource(env.MapSource(nil, nil)))
+
+	if err == nil || errors.Is(err, env.ErrFieldRequired) {
+		return nil
+	}
+	var errs env.Errors
+	if errors.As(err, &errs) {
+		return errs[0].Err
+	}
+	return err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,39 @@
+// Package example is a configuration loaded by the code generated by envgen
+package example
+
+import (
+	"log/slog"
+	"time"
+)
+
+//go:generate go run github.com/xdrm-io/env/cmd/envgen -type Config
+
+// DB is a nested configuration
+type DB struct {
+	Host     string `env:"HOST,required"`
+	Port     uint16 `env:"PORT,default=5432"`
+	Password []byte `env:"PASSWORD,secret"`
+	// dsn is private state, skipped like by env.ReadStruct
+	dsn string
+}
+
+// Config is the configuration of the example
+type Config struct {
+	DB       DB                  `envPrefix:"DB_"`
+	Replica  *DB                 `envPrefix:"REPLICA_"`
+	Mode     string              `env:"MODE,default=dev"`
+	Debug    bool                `env:"DEBUG"`
+	Timeout  time.Duration       `env:"TIMEOUT,default=5s"`
+	Since    *time.Time          `env:"SINCE"`
+	Level    slog.Level          `env:"LOG_LEVEL,default=info"`
+	Ratio    float64             `env:"RATIO"`
+	Workers  *int                `env:"WORKERS"`
+	Brokers  []string            `env:"BROKERS"`
+	Ports    []int               `env:"PORTS,sep=;"`
+	Weights  [2]float32          `env:"WEIGHTS"`
+	Limits   map[string]int      `env:"LIMITS,sep=;,kvsep=="`
+	Features map[string]struct{} `env:"FEATURES"`
+	Next     *Config             `envPrefix:"NEXT_"`
+	Ignored  string
+	Logger   *slog.Logger
+}
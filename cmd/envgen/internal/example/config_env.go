@@ -0,0 +1,320 @@
+// Code generated by envgen; DO NOT EDIT.
+
+package example
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xdrm-io/env"
+)
+
+// LoadFromEnv fills the fields of c from the environment like env.ReadStruct,
+// without reflection. Only the WithSource and WithFilePolicy options apply.
+func (c *Config) LoadFromEnv(opts ...env.Option) error {
+	var errs env.Errors
+	fail := func(field, key string, err error) {
+		errs = append(errs, &env.FieldError{Field: field, Key: key, Err: err})
+	}
+	lookup := func(set *bool, key, def string, hasDefault, required bool) (string, bool, error) {
+		raw, origin, err := env.Lookup(key, opts...)
+		if set != nil && origin.Kind != env.OriginUnset {
+			*set = true
+		}
+		switch {
+		case err != nil:
+			return "", false, fmt.Errorf("%w: %w", env.ErrFieldFile, err)
+		case origin.Kind != env.OriginUnset:
+			return raw, true, nil
+		case required:
+			return "", false, env.ErrFieldRequired
+		}
+		return def, hasDefault, nil
+	}
+	parseFloat32 := func(raw string) (float32, error) {
+		v, err := strconv.ParseFloat(raw, 32)
+		return float32(v), err
+	}
+	parseFloat64 := func(raw string) (float64, error) {
+		v, err := strconv.ParseFloat(raw, 64)
+		return float64(v), err
+	}
+	parseInt := func(raw string) (int, error) {
+		v, err := strconv.ParseInt(raw, 10, 64)
+		return int(v), err
+	}
+	parseTime := func(raw string) (time.Time, error) {
+		return time.Parse(time.RFC3339, raw)
+	}
+	parseUint16 := func(raw string) (uint16, error) {
+		v, err := strconv.ParseUint(raw, 10, 16)
+		return uint16(v), err
+	}
+
+	// DB.Host
+	if raw, ok, err := lookup(nil, "DB_HOST", "", false, true); err != nil {
+		fail("DB.Host", "DB_HOST", err)
+	} else if ok {
+		c.DB.Host = raw
+	}
+
+	// DB.Port
+	if raw, ok, err := lookup(nil, "DB_PORT", "5432", true, false); err != nil {
+		fail("DB.Port", "DB_PORT", err)
+	} else if ok {
+		if v, err := parseUint16(raw); err != nil {
+			fail("DB.Port", "DB_PORT", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.DB.Port = v
+		}
+	}
+
+	// DB.Password
+	if raw, ok, err := lookup(nil, "DB_PASSWORD", "", false, false); err != nil {
+		fail("DB.Password", "DB_PASSWORD", err)
+	} else if ok {
+		c.DB.Password = []byte(raw)
+	}
+
+	// Replica
+	{
+		n1 := c.Replica
+		if n1 == nil {
+			n1 = new(DB)
+		}
+		set1, errs1 := false, len(errs)
+
+		// Replica.Host
+		if raw, ok, err := lookup(&set1, "REPLICA_HOST", "", false, true); err != nil {
+			fail("Replica.Host", "REPLICA_HOST", err)
+		} else if ok {
+			n1.Host = raw
+		}
+
+		// Replica.Port
+		if raw, ok, err := lookup(&set1, "REPLICA_PORT", "5432", true, false); err != nil {
+			fail("Replica.Port", "REPLICA_PORT", err)
+		} else if ok {
+			if v, err := parseUint16(raw); err != nil {
+				fail("Replica.Port", "REPLICA_PORT", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+			} else {
+				n1.Port = v
+			}
+		}
+
+		// Replica.Password
+		if raw, ok, err := lookup(&set1, "REPLICA_PASSWORD", "", false, false); err != nil {
+			fail("Replica.Password", "REPLICA_PASSWORD", err)
+		} else if ok {
+			n1.Password = []byte(raw)
+		}
+		if set1 {
+			c.Replica = n1
+		} else {
+			errs = errs[:errs1]
+		}
+	}
+
+	// Mode
+	if raw, ok, err := lookup(nil, "MODE", "dev", true, false); err != nil {
+		fail("Mode", "MODE", err)
+	} else if ok {
+		c.Mode = raw
+	}
+
+	// Debug
+	if raw, ok, err := lookup(nil, "DEBUG", "", false, false); err != nil {
+		fail("Debug", "DEBUG", err)
+	} else if ok {
+		if v, err := strconv.ParseBool(raw); err != nil {
+			fail("Debug", "DEBUG", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.Debug = v
+		}
+	}
+
+	// Timeout
+	if raw, ok, err := lookup(nil, "TIMEOUT", "5s", true, false); err != nil {
+		fail("Timeout", "TIMEOUT", err)
+	} else if ok {
+		if v, err := time.ParseDuration(raw); err != nil {
+			fail("Timeout", "TIMEOUT", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.Timeout = v
+		}
+	}
+
+	// Since
+	if raw, ok, err := lookup(nil, "SINCE", "", false, false); err != nil {
+		fail("Since", "SINCE", err)
+	} else if ok {
+		if v, err := parseTime(raw); err != nil {
+			fail("Since", "SINCE", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.Since = &v
+		}
+	}
+
+	// Level
+	if raw, ok, err := lookup(nil, "LOG_LEVEL", "info", true, false); err != nil {
+		fail("Level", "LOG_LEVEL", err)
+	} else if ok {
+		if v, err := env.ParseLevel(raw); err != nil {
+			fail("Level", "LOG_LEVEL", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.Level = v
+		}
+	}
+
+	// Ratio
+	if raw, ok, err := lookup(nil, "RATIO", "", false, false); err != nil {
+		fail("Ratio", "RATIO", err)
+	} else if ok {
+		if v, err := parseFloat64(raw); err != nil {
+			fail("Ratio", "RATIO", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.Ratio = v
+		}
+	}
+
+	// Workers
+	if raw, ok, err := lookup(nil, "WORKERS", "", false, false); err != nil {
+		fail("Workers", "WORKERS", err)
+	} else if ok {
+		if v, err := parseInt(raw); err != nil {
+			fail("Workers", "WORKERS", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.Workers = &v
+		}
+	}
+
+	// Brokers
+	if raw, ok, err := lookup(nil, "BROKERS", "", false, false); err != nil {
+		fail("Brokers", "BROKERS", err)
+	} else if ok {
+		if v, err := func() ([]string, error) {
+			items, err := env.SplitList(raw, ",")
+			if err != nil {
+				return nil, err
+			}
+			list := make([]string, len(items))
+			for i, item := range items {
+				list[i] = item
+			}
+			return list, nil
+		}(); err != nil {
+			fail("Brokers", "BROKERS", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.Brokers = v
+		}
+	}
+
+	// Ports
+	if raw, ok, err := lookup(nil, "PORTS", "", false, false); err != nil {
+		fail("Ports", "PORTS", err)
+	} else if ok {
+		if v, err := func() ([]int, error) {
+			items, err := env.SplitList(raw, ";")
+			if err != nil {
+				return nil, err
+			}
+			list := make([]int, len(items))
+			for i, item := range items {
+				if list[i], err = parseInt(item); err != nil {
+					return nil, fmt.Errorf("item %d: %w", i, err)
+				}
+			}
+			return list, nil
+		}(); err != nil {
+			fail("Ports", "PORTS", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.Ports = v
+		}
+	}
+
+	// Weights
+	if raw, ok, err := lookup(nil, "WEIGHTS", "", false, false); err != nil {
+		fail("Weights", "WEIGHTS", err)
+	} else if ok {
+		if v, err := func() ([2]float32, error) {
+			items, err := env.SplitList(raw, ",")
+			if err != nil {
+				return [2]float32{}, err
+			}
+			var list [2]float32
+			if len(items) != 2 {
+				return list, fmt.Errorf("expected 2 items, got %d", len(items))
+			}
+			for i, item := range items {
+				if list[i], err = parseFloat32(item); err != nil {
+					return [2]float32{}, fmt.Errorf("item %d: %w", i, err)
+				}
+			}
+			return list, nil
+		}(); err != nil {
+			fail("Weights", "WEIGHTS", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.Weights = v
+		}
+	}
+
+	// Limits
+	if raw, ok, err := lookup(nil, "LIMITS", "", false, false); err != nil {
+		fail("Limits", "LIMITS", err)
+	} else if ok {
+		if v, err := func() (map[string]int, error) {
+			entries, err := env.SplitList(raw, ";")
+			if err != nil {
+				return nil, err
+			}
+			m := make(map[string]int, len(entries))
+			for _, entry := range entries {
+				rawKey, rawValue, ok := strings.Cut(entry, "=")
+				if !ok {
+					return nil, fmt.Errorf("missing %q in map entry %q", "=", entry)
+				}
+				key := strings.TrimSpace(rawKey)
+				value, err := parseInt(strings.TrimSpace(rawValue))
+				if err != nil {
+					return nil, fmt.Errorf("map value %q: %w", rawValue, err)
+				}
+				m[key] = value
+			}
+			return m, nil
+		}(); err != nil {
+			fail("Limits", "LIMITS", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.Limits = v
+		}
+	}
+
+	// Features
+	if raw, ok, err := lookup(nil, "FEATURES", "", false, false); err != nil {
+		fail("Features", "FEATURES", err)
+	} else if ok {
+		if v, err := func() (map[string]struct{}, error) {
+			entries, err := env.SplitList(raw, ",")
+			if err != nil {
+				return nil, err
+			}
+			m := make(map[string]struct{}, len(entries))
+			for _, entry := range entries {
+				rawKey := entry
+				key := strings.TrimSpace(rawKey)
+				m[key] = struct{}{}
+			}
+			return m, nil
+		}(); err != nil {
+			fail("Features", "FEATURES", fmt.Errorf("%w: %w", env.ErrFieldDecode, err))
+		} else {
+			c.Features = v
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
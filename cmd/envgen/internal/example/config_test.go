@@ -0,0 +1,102 @@
+package example_test
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+	"github.com/xdrm-io/env/cmd/envgen/internal/example"
+)
+
+// TestLoadFromEnv checks the generated loader behaves like ReadStruct
+func TestLoadFromEnv(t *testing.T) {
+	t.Parallel()
+
+	files := fstest.MapFS{"run/secrets/db": {Data: []byte("secret")}}
+
+	tt := []struct {
+		name    string
+		vars    map[string]string
+		wantErr bool
+		check   func(t *testing.T, c example.Config)
+	}{
+		{
+			name: "defaults",
+			vars: map[string]string{"DB_HOST": "localhost"},
+			check: func(t *testing.T, c example.Config) {
+				require.Equal(t, "localhost", c.DB.Host)
+				require.Equal(t, uint16(5432), c.DB.Port)
+				require.Nil(t, c.Replica)
+				require.Equal(t, "dev", c.Mode)
+				require.Equal(t, 5*time.Second, c.Timeout)
+			},
+		},
+		{
+			name: "all set",
+			vars: map[string]string{
+				"DB_HOST":          "localhost",
+				"DB_PORT":          "5433",
+				"DB_PASSWORD_FILE": "/run/secrets/db",
+				"REPLICA_HOST":     "replica",
+				"MODE":             "prod",
+				"DEBUG":            "true",
+				"TIMEOUT":          "1m",
+				"SINCE":            "2024-01-02T03:04:05Z",
+				"LOG_LEVEL":        "warn",
+				"RATIO":            "0.5",
+				"WORKERS":          "4",
+				"BROKERS":          `kafka-1:9092, "kafka,2:9092"`,
+				"PORTS":            "80;443",
+				"WEIGHTS":          "0.25,0.75",
+				"LIMITS":           "a=1;b=2",
+				"FEATURES":         "x, y",
+				"NEXT_MODE":        "ignored",
+			},
+		},
+		{
+			name: "errors",
+			vars: map[string]string{
+				"DB_PORT":          "99999",
+				"REPLICA_PORT":     "1",
+				"DB_PASSWORD_FILE": "/run/secrets/missing",
+				"DEBUG":            "maybe",
+				"SINCE":            "yesterday",
+				"LOG_LEVEL":        "trace",
+				"WORKERS":          "four",
+				"PORTS":            "80;http",
+				"WEIGHTS":          "1",
+				"LIMITS":           "a",
+				"BROKERS":          `"unterminated`,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := env.WithSource(env.MapSource(tc.vars, files))
+
+			var expected example.Config
+			expectedErr := env.ReadStruct(&expected, src)
+
+			var got example.Config
+			err := got.LoadFromEnv(src)
+
+			require.Equal(t, expected, got)
+			if !tc.wantErr {
+				require.NoError(t, expectedErr)
+				require.NoError(t, err)
+				if tc.check != nil {
+					tc.check(t, got)
+				}
+				return
+			}
+			require.Error(t, expectedErr)
+			require.EqualError(t, err, expectedErr.Error())
+		})
+	}
+}
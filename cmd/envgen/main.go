@@ -0,0 +1,72 @@
+// Command envgen generates reflection-free loaders for structs carrying `env`
+// tags. For each type it emits a method
+//
+//	func (c *T) LoadFromEnv(opts ...env.Option) error
+//
+// filling the fields like env.ReadStruct, with the same errors. Unsupported
+// types and invalid tags fail at generate time instead of at startup.
+//
+// Usage:
+//
+//	//go:generate go run github.com/xdrm-io/env/cmd/envgen -type Config
+//
+// The generated code reads variables with env.Lookup, `_FILE` variants
+// included, and decodes them with strconv, time and the env helpers. It only
+// honors the WithSource and WithFilePolicy options.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+func run(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("envgen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var (
+		typeNames = flags.String("type", "", "comma-separated list of struct `names`")
+		output    = flags.String("output", "", "output `file`, defaults to <type>_env.go")
+	)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: envgen -type T [-output file] [dir]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *typeNames == "" || flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+	types := strings.Split(*typeNames, ",")
+	if *output == "" {
+		*output = strings.ToLower(types[0]) + "_env.go"
+	}
+	if !filepath.IsAbs(*output) {
+		*output = filepath.Join(dir, *output)
+	}
+
+	src, err := generate(dir, types, filepath.Base(*output))
+	if err != nil {
+		fmt.Fprintf(stderr, "envgen: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		fmt.Fprintf(stderr, "envgen: %v\n", err)
+		return 1
+	}
+	return 0
+}
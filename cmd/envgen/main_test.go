@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestGenerate checks the generated example is up to date
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	expected, err := os.ReadFile("internal/example/config_env.go")
+	require.NoError(t, err)
+
+	got, err := generate("internal/example", []string{"Config"}, "config_env.go")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(got), "run go generate ./...")
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name:     "not found",
+			src:      "type Other struct{}",
+			expected: "struct type Config not found in package config",
+		},
+		{
+			name:     "no field",
+			src:      "type Config struct{ Name string }",
+			expected: "struct type Config has no env field",
+		},
+		{
+			name:     "unsupported type",
+			src:      "type Config struct{ C chan int `env:\"C\"` }",
+			expected: `config.go:3:21: field C: unsupported field type: "chan int"`,
+		},
+		{
+			name:     "unsupported external type",
+			src:      "import \"net\"\ntype Config struct{ IP net.IP `env:\"IP\"` }",
+			expected: `config.go:4:21: field IP: unsupported field type: "net.IP"`,
+		},
+		{
+			name:     "unexported",
+			src:      "type Config struct{ name string `env:\"NAME\"` }",
+			expected: "config.go:3:21: field name: field is unexported",
+		},
+		{
+			name:     "unknown option",
+			src:      "type Config struct{ Name string `env:\"NAME,requred\"` }",
+			expected: `config.go:3:21: field Name: invalid env tag: unknown option "requred"`,
+		},
+		{
+			name:     "unsupported option",
+			src:      "type Config struct{ Port int `env:\"PORT,min=1\"` }",
+			expected: `config.go:3:21: field Port: option "min" is not supported, use env.ReadStruct`,
+		},
+		{
+			name:     "invalid default",
+			src:      "type Config struct{ Port int `env:\"PORT,default=http\"` }",
+			expected: `config.go:3:21: field Port: invalid default value: strconv.ParseInt: parsing "http": invalid syntax`,
+		},
+		{
+			name: "nested",
+			src: "type DB struct {\nPort int `env:\"PORT,required,default=1\"`\nname string\n}\n" +
+				"type Config struct{ DB *DB `envPrefix:\"DB_\"` }",
+			expected: `config.go:4:1: field DB.Port: invalid env tag: required field cannot have a default`,
+		},
+		{
+			name:     "prefixed external type",
+			src:      "import \"net/http\"\ntype Config struct{ Client http.Client `envPrefix:\"HTTP_\"` }",
+			expected: `config.go:4:21: field Client: nested type http.Client of another package is not supported`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			src := "package config\n\n" + tc.src + "\n"
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "config.go"), []byte(src), 0o600))
+
+			_, err := generate(dir, []string{"Config"}, "config_env.go")
+			require.Error(t, err)
+			require.Equal(t, tc.expected, strings.ReplaceAll(err.Error(), dir+string(filepath.Separator), ""))
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := "package config\n\ntype Config struct{ Name string `env:\"NAME\"` }\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.go"), []byte(src), 0o600))
+
+	require.Equal(t, 0, run([]string{"-type", "Config", dir}, os.Stderr))
+	require.FileExists(t, filepath.Join(dir, "config_env.go"))
+
+	require.Equal(t, 2, run([]string{dir}, os.Stderr))
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xdrm-io/env"
+	"github.com/xdrm-io/env/internal/envtag"
+)
+
+// tag is the subset of the `env` tag options supported by the generated code
+type tag struct {
+	key        string
+	required   bool
+	def        string
+	hasDefault bool
+	sep, kvsep string
+}
+
+func (t tag) separator() string {
+	if t.sep == "" {
+		return ","
+	}
+	return t.sep
+}
+
+func (t tag) kvSeparator() string {
+	if t.kvsep == "" {
+		return ":"
+	}
+	return t.kvsep
+}
+
+// unsupportedOptions are the `env` tag options only env.ReadStruct handles
+var unsupportedOptions = []string{"nofile", "min", "max", "minlen", "maxlen", "oneof", "regex", "notempty", "validate", "expand", "alias", "deprecated"}
+
+// parseTag parses an `env` tag already validated by checkTag
+func parseTag(raw string) (tag, error) {
+	parts := envtag.Split(raw)
+	t := tag{key: parts[0]}
+	for _, part := range parts[1:] {
+		name, value, _ := strings.Cut(part, "=")
+		switch name {
+		case "required":
+			t.required = true
+		case "default":
+			t.def, t.hasDefault = value, true
+		case "sep":
+			t.sep = value
+		case "kvsep":
+			t.kvsep = value
+		case "secret":
+		default:
+			if slices.Contains(unsupportedOptions, name) {
+				return t, fmt.Errorf("option %q is not supported, use env.ReadStruct", name)
+			}
+			return t, fmt.Errorf("%w: unknown option %q", env.ErrFieldTag, part)
+		}
+	}
+	return t, nil
+}
+
+// scalar is a type decoded by the default decoders of env.ReadStruct
+type scalar struct {
+	// name is the type in the generated code
+	name  string
+	rtype reflect.Type
+	// decode is the function decoding raw values, empty when decoding cannot
+	// fail
+	decode string
+	// closure is the body of the decode function when it is declared by the
+	// loader as `func(raw string) (T, error)`
+	closure string
+}
+
+// convert returns the expression converting {arg} when decoding cannot fail
+func (s scalar) convert(arg string) string {
+	if s.name == "string" {
+		return arg
+	}
+	return s.name + "(" + arg + ")"
+}
+
+func intScalar[T any](name, parse string, bits int) scalar {
+	return scalar{
+		name:    name,
+		rtype:   reflect.TypeFor[T](),
+		decode:  "parse" + strings.ToUpper(name[:1]) + name[1:],
+		closure: fmt.Sprintf("v, err := strconv.%s(raw, 10, %d)\nreturn %s(v), err", parse, bits, name),
+	}
+}
+
+func floatScalar[T any](name string, bits int) scalar {
+	return scalar{
+		name:    name,
+		rtype:   reflect.TypeFor[T](),
+		decode:  "parse" + strings.ToUpper(name[:1]) + name[1:],
+		closure: fmt.Sprintf("v, err := strconv.ParseFloat(raw, %d)\nreturn %s(v), err", bits, name),
+	}
+}
+
+// scalars are the supported types, by name
+var scalars = map[string]scalar{
+	"string":        {name: "string", rtype: reflect.TypeFor[string]()},
+	"[]byte":        {name: "[]byte", rtype: reflect.TypeFor[[]byte]()},
+	"int":           intScalar[int]("int", "ParseInt", 64),
+	"int8":          intScalar[int8]("int8", "ParseInt", 8),
+	"int16":         intScalar[int16]("int16", "ParseInt", 16),
+	"int32":         intScalar[int32]("int32", "ParseInt", 32),
+	"int64":         intScalar[int64]("int64", "ParseInt", 64),
+	"uint":          intScalar[uint]("uint", "ParseUint", 64),
+	"uint8":         intScalar[uint8]("uint8", "ParseUint", 8),
+	"uint16":        intScalar[uint16]("uint16", "ParseUint", 16),
+	"uint32":        intScalar[uint32]("uint32", "ParseUint", 32),
+	"uint64":        intScalar[uint64]("uint64", "ParseUint", 64),
+	"float32":       floatScalar[float32]("float32", 32),
+	"float64":       floatScalar[float64]("float64", 64),
+	"bool":          {name: "bool", rtype: reflect.TypeFor[bool](), decode: "strconv.ParseBool"},
+	"time.Duration": {name: "time.Duration", rtype: reflect.TypeFor[time.Duration](), decode: "time.ParseDuration"},
+	"time.Time": {
+		name:    "time.Time",
+		rtype:   reflect.TypeFor[time.Time](),
+		decode:  "parseTime",
+		closure: "return time.Parse(time.RFC3339, raw)",
+	},
+	"slog.Level": {name: "slog.Level", rtype: reflect.TypeFor[slog.Level](), decode: "env.ParseLevel"},
+}
+
+// aliases of the scalar types
+func init() {
+	scalars["byte"] = scalars["uint8"]
+	scalars["rune"] = scalars["int32"]
+}
+
+// resolveScalar returns the scalar type {expr} refers to, {imports} are the
+// imports of its file by name
+func resolveScalar(expr ast.Expr, imports map[string]string) (scalar, error) {
+	name := types.ExprString(expr)
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		if x, ok := sel.X.(*ast.Ident); ok {
+			// canonical package name, e.g. `slog` for an aliased "log/slog"
+			if importPath, ok := imports[x.Name]; ok {
+				name = importPath[strings.LastIndex(importPath, "/")+1:] + "." + sel.Sel.Name
+			}
+		}
+	}
+	if name == "[]uint8" {
+		name = "[]byte"
+	}
+	s, ok := scalars[name]
+	if !ok {
+		return s, fmt.Errorf("%w: %q", env.ErrFieldUnsupported, types.ExprString(expr))
+	}
+	return s, nil
+}
+
+type typeKind int
+
+const (
+	kindScalar typeKind = iota
+	kindSlice
+	kindArray
+	kindMap
+	kindSet
+)
+
+// fieldType is the type of a tagged field
+type fieldType struct {
+	kind typeKind
+	// elem is the scalar, the item of lists or the value of maps
+	elem scalar
+	// key is the key of maps and sets
+	key scalar
+	// length is the length of arrays
+	length  int
+	pointer bool
+}
+
+// resolveType returns the type of a field declared as {expr}
+func resolveType(expr ast.Expr, imports map[string]string) (fieldType, error) {
+	var ft fieldType
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr, ft.pointer = star.X, true
+	}
+	if s, err := resolveScalar(expr, imports); err == nil {
+		ft.kind, ft.elem = kindScalar, s
+		return ft, nil
+	}
+
+	var err error
+	switch t := expr.(type) {
+	case *ast.ArrayType:
+		ft.kind = kindSlice
+		if t.Len != nil {
+			ft.kind = kindArray
+			lit, ok := t.Len.(*ast.BasicLit)
+			if !ok {
+				return ft, errors.New("array length must be an integer literal")
+			}
+			if ft.length, err = strconv.Atoi(lit.Value); err != nil {
+				return ft, fmt.Errorf("invalid array length: %w", err)
+			}
+		}
+		ft.elem, err = resolveScalar(t.Elt, imports)
+		return ft, err
+
+	case *ast.MapType:
+		ft.kind = kindMap
+		if ft.key, err = resolveScalar(t.Key, imports); err != nil {
+			return ft, err
+		}
+		if st, ok := t.Value.(*ast.StructType); ok && len(st.Fields.List) == 0 {
+			ft.kind = kindSet
+			return ft, nil
+		}
+		ft.elem, err = resolveScalar(t.Value, imports)
+		return ft, err
+	}
+	return ft, fmt.Errorf("%w: %q", env.ErrFieldUnsupported, types.ExprString(expr))
+}
+
+// goType returns the type in the generated code, pointer excluded
+func (ft fieldType) goType() string {
+	switch ft.kind {
+	case kindSlice:
+		return "[]" + ft.elem.name
+	case kindArray:
+		return fmt.Sprintf("[%d]%s", ft.length, ft.elem.name)
+	case kindMap:
+		return fmt.Sprintf("map[%s]%s", ft.key.name, ft.elem.name)
+	case kindSet:
+		return fmt.Sprintf("map[%s]struct{}", ft.key.name)
+	}
+	return ft.elem.name
+}
+
+// zero returns the zero value of lists in the generated code
+func (ft fieldType) zero() string {
+	if ft.kind == kindArray {
+		return ft.goType() + "{}"
+	}
+	return "nil"
+}
+
+// reflectType returns the type, pointer included
+func (ft fieldType) reflectType() reflect.Type {
+	var t reflect.Type
+	switch ft.kind {
+	case kindSlice:
+		t = reflect.SliceOf(ft.elem.rtype)
+	case kindArray:
+		t = reflect.ArrayOf(ft.length, ft.elem.rtype)
+	case kindMap:
+		t = reflect.MapOf(ft.key.rtype, ft.elem.rtype)
+	case kindSet:
+		t = reflect.MapOf(ft.key.rtype, reflect.TypeFor[struct{}]())
+	default:
+		t = ft.elem.rtype
+	}
+	if ft.pointer {
+		t = reflect.PointerTo(t)
+	}
+	return t
+}
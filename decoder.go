@@ -0,0 +1,262 @@
+package env
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DecoderFn decodes a string value into a specific type
+type DecoderFn func(raw string) (any, error)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[reflect.Type]DecoderFn{
+		reflect.TypeFor[string]():        func(raw string) (any, error) { return raw, nil },
+		reflect.TypeFor[[]uint8]():       func(raw string) (any, error) { return []byte(raw), nil }, // []byte
+		reflect.TypeFor[int]():           func(raw string) (any, error) { v, err := strconv.ParseInt(raw, 10, 64); return int(v), err },
+		reflect.TypeFor[int8]():          func(raw string) (any, error) { v, err := strconv.ParseInt(raw, 10, 8); return int8(v), err },
+		reflect.TypeFor[int16]():         func(raw string) (any, error) { v, err := strconv.ParseInt(raw, 10, 16); return int16(v), err },
+		reflect.TypeFor[int32]():         func(raw string) (any, error) { v, err := strconv.ParseInt(raw, 10, 32); return int32(v), err },
+		reflect.TypeFor[int64]():         func(raw string) (any, error) { v, err := strconv.ParseInt(raw, 10, 64); return int64(v), err },
+		reflect.TypeFor[uint]():          func(raw string) (any, error) { v, err := strconv.ParseUint(raw, 10, 64); return uint(v), err },
+		reflect.TypeFor[uint8]():         func(raw string) (any, error) { v, err := strconv.ParseUint(raw, 10, 8); return uint8(v), err },
+		reflect.TypeFor[uint16]():        func(raw string) (any, error) { v, err := strconv.ParseUint(raw, 10, 16); return uint16(v), err },
+		reflect.TypeFor[uint32]():        func(raw string) (any, error) { v, err := strconv.ParseUint(raw, 10, 32); return uint32(v), err },
+		reflect.TypeFor[uint64]():        func(raw string) (any, error) { v, err := strconv.ParseUint(raw, 10, 64); return uint64(v), err },
+		reflect.TypeFor[float32]():       func(raw string) (any, error) { v, err := strconv.ParseFloat(raw, 32); return float32(v), err },
+		reflect.TypeFor[float64]():       func(raw string) (any, error) { v, err := strconv.ParseFloat(raw, 64); return float64(v), err },
+		reflect.TypeFor[bool]():          func(raw string) (any, error) { v, err := strconv.ParseBool(raw); return bool(v), err },
+		reflect.TypeFor[time.Time]():     func(raw string) (any, error) { return time.Parse(time.RFC3339, raw) },
+		reflect.TypeFor[time.Duration](): func(raw string) (any, error) { return time.ParseDuration(raw) },
+		reflect.TypeFor[slog.Level]():    func(raw string) (any, error) { return ParseLevel(raw) },
+	}
+)
+
+// ParseLevel decodes a slog.Level from its case-insensitive name: "debug",
+// "info", "warn" or "error"
+func ParseLevel(raw string) (slog.Level, error) {
+	switch strings.TrimSpace(strings.ToLower(raw)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	case "info":
+		return slog.LevelInfo, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid slog.Level: %q", raw)
+	}
+}
+
+// RegisterDecoder registers the decoder of the type {t} for every subsequent
+// call, it replaces any decoder already registered for that type. It is safe
+// for concurrent use. Use WithDecoder to override a decoder for a single call
+// without altering the registry.
+func RegisterDecoder(t reflect.Type, fn DecoderFn) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[t] = fn
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	flagValueType       = reflect.TypeFor[flag.Value]()
+)
+
+// decoder returns the decoder of the type {t}, in order of precedence:
+//   - the per-call decoders
+//   - the registry
+//   - encoding.TextUnmarshaler then flag.Value, implemented by the type or a
+//     pointer to it
+//   - for named slice types, the decoder of their unnamed counterpart
+func (o *options) decoder(t reflect.Type) (DecoderFn, bool) {
+	if fn, ok := o.decoders[t]; ok {
+		return fn, true
+	}
+
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	if fn, ok := decoders[t]; ok {
+		return fn, true
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return textUnmarshalerDecoder(t), true
+	}
+	if reflect.PointerTo(t).Implements(flagValueType) {
+		return flagValueDecoder(t), true
+	}
+	if t.Kind() == reflect.Slice && t.Name() != "" {
+		fn, ok := decoders[reflect.SliceOf(t.Elem())]
+		return fn, ok
+	}
+	return nil, false
+}
+
+// fieldDecoder returns the decoder of a field of type {t}: the decoder of the
+// type itself, or a decoder built from the field {tag} for secrets, slices,
+// arrays and maps
+func (o *options) fieldDecoder(t reflect.Type, tag tag) (DecoderFn, bool) {
+	if fn, ok := o.decoder(t); ok {
+		return fn, true
+	}
+	if valueType, ok := secretValueType(t); ok {
+		return o.secretDecoder(t, valueType, tag)
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return o.listDecoder(t, tag)
+	case reflect.Map:
+		return o.mapDecoder(t, tag)
+	}
+	return nil, false
+}
+
+// secretDecoder decodes values of the Secret type {t} with the field decoder
+// of the {valueType} they hold
+func (o *options) secretDecoder(t, valueType reflect.Type, tag tag) (DecoderFn, bool) {
+	valueDecoder, ok := o.fieldDecoder(valueType, tag)
+	if !ok {
+		return nil, false
+	}
+	return func(raw string) (any, error) {
+		v, err := valueDecoder(raw)
+		if err != nil {
+			return nil, err
+		}
+		return newSecret(t, v)
+	}, true
+}
+
+// listDecoder decodes slices and arrays from items separated by {tag.sep},
+// following the grammar of SplitList. Items are decoded with the decoder of
+// the element type. Arrays require exactly as many items as their length.
+func (o *options) listDecoder(t reflect.Type, tag tag) (DecoderFn, bool) {
+	elemDecoder, ok := o.decoder(t.Elem())
+	if !ok {
+		return nil, false
+	}
+
+	sep := tag.separator()
+	return func(raw string) (any, error) {
+		items, err := SplitList(raw, sep)
+		if err != nil {
+			return nil, err
+		}
+
+		var list reflect.Value
+		if t.Kind() == reflect.Array {
+			if len(items) != t.Len() {
+				return nil, fmt.Errorf("expected %d items, got %d", t.Len(), len(items))
+			}
+			list = reflect.New(t).Elem()
+		} else {
+			list = reflect.MakeSlice(t, len(items), len(items))
+		}
+
+		for i, item := range items {
+			v, err := decodeAs(elemDecoder, item, t.Elem())
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			list.Index(i).Set(v)
+		}
+		return list.Interface(), nil
+	}, true
+}
+
+// mapDecoder decodes maps from entries separated by {tag.sep}, following the
+// grammar of SplitList. Each entry is made of a key and a value separated by
+// {tag.kvsep}, e.g. "a:1,b:2". Keys and values are decoded with the decoders
+// of their type. Maps of empty structs are sets and their entries only
+// contain a key, e.g. "a,b". The last duplicate key wins.
+func (o *options) mapDecoder(t reflect.Type, tag tag) (DecoderFn, bool) {
+	keyDecoder, ok := o.decoder(t.Key())
+	if !ok {
+		return nil, false
+	}
+
+	isSet := t.Elem().Kind() == reflect.Struct && t.Elem().NumField() == 0
+	valueDecoder, ok := o.decoder(t.Elem())
+	if !ok && !isSet {
+		return nil, false
+	}
+
+	sep, kvsep := tag.separator(), tag.kvSeparator()
+	return func(raw string) (any, error) {
+		entries, err := SplitList(raw, sep)
+		if err != nil {
+			return nil, err
+		}
+
+		m := reflect.MakeMap(t)
+		for _, entry := range entries {
+			rawKey, rawValue, hasValue := strings.Cut(entry, kvsep)
+			if isSet {
+				rawKey, hasValue = entry, true
+			}
+			if !hasValue {
+				return nil, fmt.Errorf("missing %q in map entry %q", kvsep, entry)
+			}
+
+			key, err := decodeAs(keyDecoder, strings.TrimSpace(rawKey), t.Key())
+			if err != nil {
+				return nil, fmt.Errorf("map key %q: %w", rawKey, err)
+			}
+			if isSet {
+				m.SetMapIndex(key, reflect.Zero(t.Elem()))
+				continue
+			}
+			value, err := decodeAs(valueDecoder, strings.TrimSpace(rawValue), t.Elem())
+			if err != nil {
+				return nil, fmt.Errorf("map value %q: %w", rawValue, err)
+			}
+			m.SetMapIndex(key, value)
+		}
+		return m.Interface(), nil
+	}, true
+}
+
+// decodeAs decodes {raw} with {fn} and checks the result is a {t}
+func decodeAs(fn DecoderFn, raw string, t reflect.Type) (reflect.Value, error) {
+	decoded, err := fn(raw)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	v := reflect.ValueOf(decoded)
+	if !v.IsValid() || !v.Type().AssignableTo(t) {
+		return reflect.Value{}, fmt.Errorf("cannot assign %T to %s", decoded, t)
+	}
+	return v, nil
+}
+
+// textUnmarshalerDecoder decodes values of the type {t} through the
+// encoding.TextUnmarshaler implemented by *t
+func textUnmarshalerDecoder(t reflect.Type) DecoderFn {
+	return func(raw string) (any, error) {
+		v := reflect.New(t)
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw)); err != nil {
+			return nil, err
+		}
+		return v.Elem().Interface(), nil
+	}
+}
+
+// flagValueDecoder decodes values of the type {t} through the flag.Value
+// implemented by *t
+func flagValueDecoder(t reflect.Type) DecoderFn {
+	return func(raw string) (any, error) {
+		v := reflect.New(t)
+		if err := v.Interface().(flag.Value).Set(raw); err != nil {
+			return nil, err
+		}
+		return v.Elem().Interface(), nil
+	}
+}
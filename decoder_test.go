@@ -0,0 +1,168 @@
+package env_test
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+type tenantID string
+
+func decodeTenantID(raw string) (any, error) {
+	if !strings.HasPrefix(raw, "tenant-") {
+		return nil, fmt.Errorf("invalid tenant id: %q", raw)
+	}
+	return tenantID(raw), nil
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	type config struct {
+		Tenant  tenantID   `env:"TENANT"`
+		Tenants []tenantID `env:"TENANTS"`
+		Ptr     *tenantID  `env:"PTR"`
+	}
+	env.RegisterDecoder(reflect.TypeFor[tenantID](), decodeTenantID)
+	env.RegisterDecoder(reflect.TypeFor[[]tenantID](), func(raw string) (any, error) {
+		var ids []tenantID
+		for _, part := range strings.Split(raw, ",") {
+			id, err := decodeTenantID(part)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id.(tenantID))
+		}
+		return ids, nil
+	})
+
+	t.Run("registered decoder", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("TENANT", "tenant-a")
+		os.Setenv("TENANTS", "tenant-b,tenant-c")
+		os.Setenv("PTR", "tenant-d")
+
+		var cfg config
+		require.NoError(t, env.ReadStruct(&cfg))
+		require.Equal(t, tenantID("tenant-a"), cfg.Tenant)
+		require.Equal(t, []tenantID{"tenant-b", "tenant-c"}, cfg.Tenants)
+		require.NotNil(t, cfg.Ptr)
+		require.Equal(t, tenantID("tenant-d"), *cfg.Ptr)
+	})
+
+	t.Run("registered decoder fails", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("TENANT", "a")
+
+		var cfg config
+		require.ErrorIs(t, env.ReadStruct(&cfg), env.ErrFieldDecode)
+	})
+
+	t.Run("per-call decoder takes precedence", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("TENANT", "a")
+
+		var cfg config
+		err := env.ReadStruct(&cfg, env.WithDecoder(reflect.TypeFor[tenantID](), func(raw string) (any, error) {
+			return tenantID("tenant-" + raw), nil
+		}))
+		require.NoError(t, err)
+		require.Equal(t, tenantID("tenant-a"), cfg.Tenant)
+
+		// the registry is left untouched
+		require.ErrorIs(t, env.ReadStruct(&cfg), env.ErrFieldDecode)
+	})
+
+	t.Run("decoder returning the wrong type fails", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("TENANT", "tenant-a")
+
+		var cfg config
+		err := env.ReadStruct(&cfg, env.WithDecoder(reflect.TypeFor[tenantID](), func(raw string) (any, error) {
+			return raw, nil
+		}))
+		require.ErrorIs(t, err, env.ErrFieldDecode)
+	})
+
+	t.Run("concurrent registration", func(t *testing.T) {
+		type other string
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				env.RegisterDecoder(reflect.TypeFor[other](), func(raw string) (any, error) { return other(raw), nil })
+			}()
+			go func() {
+				defer wg.Done()
+				var cfg config
+				_ = env.ReadStruct(&cfg)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+type mode string
+
+func (m *mode) String() string { return string(*m) }
+
+func (m *mode) Set(raw string) error {
+	switch raw {
+	case "dev", "prod":
+		*m = mode(raw)
+		return nil
+	default:
+		return fmt.Errorf("invalid mode: %q", raw)
+	}
+}
+
+func TestReadStruct_Unmarshalers(t *testing.T) {
+	type config struct {
+		Addr   netip.Addr   `env:"ADDR"`
+		Prefix netip.Prefix `env:"PREFIX"`
+		Big    *big.Int     `env:"BIG"`
+		IP     net.IP       `env:"IP"`
+		Mode   mode         `env:"MODE"`
+	}
+
+	t.Run("nominal", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ADDR", "10.0.0.1")
+		os.Setenv("PREFIX", "10.0.0.0/8")
+		os.Setenv("BIG", "123456789012345678901234567890")
+		os.Setenv("IP", "::1")
+		os.Setenv("MODE", "prod")
+
+		var cfg config
+		require.NoError(t, env.ReadStruct(&cfg))
+		require.Equal(t, netip.MustParseAddr("10.0.0.1"), cfg.Addr)
+		require.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), cfg.Prefix)
+		require.Equal(t, "123456789012345678901234567890", cfg.Big.String())
+		require.Equal(t, net.ParseIP("::1"), cfg.IP)
+		require.Equal(t, mode("prod"), cfg.Mode)
+	})
+
+	t.Run("text unmarshaler fails", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ADDR", "invalid")
+
+		var cfg config
+		require.ErrorIs(t, env.ReadStruct(&cfg), env.ErrFieldDecode)
+	})
+
+	t.Run("flag value fails", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("MODE", "staging")
+
+		var cfg config
+		require.ErrorIs(t, env.ReadStruct(&cfg), env.ErrFieldDecode)
+	})
+}
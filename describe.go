@@ -0,0 +1,265 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Var describes an environment variable bound to a struct field
+type Var struct {
+	// Key is the variable, prefixes included
+	Key string
+	// Field is the path of the field, e.g. "DB.Host"
+	Field string
+	// Type is the Go type of the field, e.g. "time.Duration"
+	Type string
+	// Required is set for `required` fields
+	Required bool
+	// Default is the raw default value, when HasDefault is set
+	Default    string
+	HasDefault bool
+	// Description comes from the `desc` struct tag
+	Description string
+	// Secret is set for `secret` and Secret[T] fields
+	Secret bool
+	// FileKey is the `_FILE` variant of the key, empty for `nofile` fields
+	FileKey string
+	// Aliases are the `alias` and `deprecated` keys read when Key is not set,
+	// by order of precedence, prefixes included
+	Aliases []string
+	// Deprecated are the `deprecated` keys among Aliases
+	Deprecated []string
+	// Tag is the raw `env` tag
+	Tag string
+}
+
+// Describe returns the variables bound to the fields of the struct pointed to
+// by {v}, walking the same tags as ReadStruct. It fails with the same errors
+// as ReadStruct for invalid tags and unsupported types, and with ErrFieldTag
+// for unknown tag options that ReadStruct ignores.
+func Describe(v any, opts ...Option) ([]Var, error) {
+	fields, err := describe(v, newOptions(opts), true)
+	vars := make([]Var, len(fields))
+	for i, f := range fields {
+		vars[i] = f.Var
+	}
+	return vars, err
+}
+
+// describedField is a Var along with its parsed tag
+type describedField struct {
+	Var
+	tag tag
+	// typ is the type of the field, pointers excluded
+	typ reflect.Type
+	// index is the index sequence of the field for reflect.Value.FieldByIndex
+	index []int
+}
+
+// describe walks the fields of the struct type pointed to by {v}. Unknown tag
+// options are rejected when {strict} is set.
+func describe(v any, o *options, strict bool) ([]describedField, error) {
+	rt := reflect.TypeOf(v)
+	if rt == nil || rt.Kind() != reflect.Ptr {
+		return nil, ErrNotPtr
+	}
+	rt = rt.Elem()
+	if rt.Kind() != reflect.Struct {
+		return nil, ErrNotStructPtr
+	}
+
+	d := &describer{opts: o, strict: strict}
+	d.describe(rt, o.prefix, "", nil)
+	if len(d.errs) > 0 {
+		return d.fields, d.errs
+	}
+	return d.fields, nil
+}
+
+// describeValue describes the struct {v}, or the struct it points to, and
+// returns the struct value
+func describeValue(v any, o *options) (reflect.Value, []describedField, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Struct {
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		rv = ptr
+	}
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return reflect.Value{}, nil, ErrNotPtr
+	}
+
+	fields, err := describe(rv.Interface(), o, false)
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	return rv.Elem(), fields, nil
+}
+
+// valueOf returns the value of the field in the struct {rv}, pointers
+// dereferenced. It returns false when the field or a nested struct pointer is
+// nil.
+func (f describedField) valueOf(rv reflect.Value) (reflect.Value, bool) {
+	fieldValue, err := rv.FieldByIndexErr(f.index)
+	if err != nil {
+		return reflect.Value{}, false
+	}
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return reflect.Value{}, false
+		}
+		fieldValue = fieldValue.Elem()
+	}
+	return fieldValue, true
+}
+
+// keys returns the keys the field is read from by order of precedence
+func (f describedField) keys() []string {
+	return append([]string{f.Key}, f.Aliases...)
+}
+
+// describer walks the fields of a struct type like ReadStruct walks its
+// values
+type describer struct {
+	opts *options
+	// strict rejects unknown tag options
+	strict  bool
+	parents []reflect.Type
+	fields  []describedField
+	errs    Errors
+}
+
+func (d *describer) describe(rt reflect.Type, prefix, path string, index []int) {
+	d.parents = append(d.parents, rt)
+	defer func() { d.parents = d.parents[:len(d.parents)-1] }()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name := path + field.Name
+		fieldIndex := append(slices.Clip(index), i)
+
+		if !field.IsExported() {
+			if path == "" {
+				d.errs = append(d.errs, &FieldError{Field: name, Err: ErrFieldUnexported})
+			}
+			continue
+		}
+
+		if d.opts.isNested(field) {
+			nested := field.Type
+			if nested.Kind() == reflect.Ptr {
+				nested = nested.Elem()
+			}
+			// a parent type would recurse forever, ReadStruct leaves it nil
+			if slices.Contains(d.parents, nested) {
+				continue
+			}
+			d.describe(nested, prefix+field.Tag.Get("envPrefix"), name+".", fieldIndex)
+			continue
+		}
+
+		rawTag := field.Tag.Get("env")
+		if rawTag == "" {
+			continue
+		}
+		tag, err := parseTag(rawTag)
+		if err == nil && d.strict {
+			err = tag.checkUnknown()
+		}
+		key := prefix + tag.key
+		if err != nil {
+			if tag.key == "" {
+				key = ""
+			}
+			d.errs = append(d.errs, &FieldError{Field: name, Key: key, Err: err})
+			continue
+		}
+
+		t := field.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		decoder, ok := d.opts.fieldDecoder(t, tag)
+		if !ok {
+			err := fmt.Errorf("%w: %q", ErrFieldUnsupported, t.String())
+			d.errs = append(d.errs, &FieldError{Field: name, Key: key, Err: err})
+			continue
+		}
+		if err := d.opts.checkRules(tag.rules, t, decoder); err != nil {
+			d.errs = append(d.errs, &FieldError{Field: name, Key: key, Err: err})
+			continue
+		}
+
+		_, isSecret := secretValueType(t)
+		v := Var{
+			Key:         key,
+			Field:       name,
+			Type:        field.Type.String(),
+			Required:    tag.required,
+			Default:     tag.def,
+			HasDefault:  tag.hasDefault,
+			Description: field.Tag.Get("desc"),
+			Secret:      tag.secret || isSecret,
+			Tag:         rawTag,
+		}
+		if !tag.noFile {
+			v.FileKey = key + d.opts.files.suffix()
+		}
+		for _, a := range tag.aliases {
+			v.Aliases = append(v.Aliases, prefix+a.key)
+			if a.deprecated {
+				v.Deprecated = append(v.Deprecated, prefix+a.key)
+			}
+		}
+		d.fields = append(d.fields, describedField{Var: v, tag: tag, typ: t, index: fieldIndex})
+	}
+}
+
+// Usage writes an aligned table of the variables bound to the fields of the
+// struct pointed to by {v}, like flag.PrintDefaults. Defaults of secret
+// fields are masked.
+func Usage(w io.Writer, v any, opts ...Option) error {
+	vars, err := Describe(v, opts...)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VARIABLE\tTYPE\tDEFAULT\tDESCRIPTION")
+	for _, v := range vars {
+		def := ""
+		switch {
+		case v.Required:
+			def = "required"
+		case v.HasDefault && v.Secret:
+			def = secretMask
+		case v.HasDefault:
+			def = v.Default
+		}
+
+		var notes []string
+		if v.Secret {
+			notes = append(notes, "secret")
+		}
+		if v.FileKey != "" {
+			notes = append(notes, "or "+v.FileKey)
+		}
+		for _, alias := range v.Aliases {
+			if slices.Contains(v.Deprecated, alias) {
+				notes = append(notes, "deprecated "+alias)
+			} else {
+				notes = append(notes, "alias "+alias)
+			}
+		}
+		desc := v.Description
+		if len(notes) > 0 {
+			desc = strings.TrimSpace(desc + " (" + strings.Join(notes, ", ") + ")")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", v.Key, v.Type, def, desc)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,123 @@
+package env_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+type describeDB struct {
+	Host     string `env:"HOST,required" desc:"database host"`
+	Password string `env:"PASSWORD,required,secret" desc:"database password"`
+}
+
+type describeConfig struct {
+	DB      describeDB    `envPrefix:"DB_"`
+	Port    int           `env:"PORT,default=8080,nofile" desc:"listening port"`
+	Timeout time.Duration `env:"TIMEOUT,default=5s"`
+	Token   string        `env:"TOKEN,secret,default=dev-token"`
+	Ignored string
+}
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	vars, err := env.Describe(&describeConfig{})
+	require.NoError(t, err)
+	require.Equal(t, []env.Var{
+		{
+			Key:         "DB_HOST",
+			Field:       "DB.Host",
+			Type:        "string",
+			Required:    true,
+			Description: "database host",
+			FileKey:     "DB_HOST_FILE",
+			Tag:         "HOST,required",
+		},
+		{
+			Key:         "DB_PASSWORD",
+			Field:       "DB.Password",
+			Type:        "string",
+			Required:    true,
+			Description: "database password",
+			Secret:      true,
+			FileKey:     "DB_PASSWORD_FILE",
+			Tag:         "PASSWORD,required,secret",
+		},
+		{
+			Key:         "PORT",
+			Field:       "Port",
+			Type:        "int",
+			Default:     "8080",
+			HasDefault:  true,
+			Description: "listening port",
+			Tag:         "PORT,default=8080,nofile",
+		},
+		{
+			Key:        "TIMEOUT",
+			Field:      "Timeout",
+			Type:       "time.Duration",
+			Default:    "5s",
+			HasDefault: true,
+			FileKey:    "TIMEOUT_FILE",
+			Tag:        "TIMEOUT,default=5s",
+		},
+		{
+			Key:        "TOKEN",
+			Field:      "Token",
+			Type:       "string",
+			Default:    "dev-token",
+			HasDefault: true,
+			Secret:     true,
+			FileKey:    "TOKEN_FILE",
+			Tag:        "TOKEN,secret,default=dev-token",
+		},
+	}, vars)
+}
+
+func TestDescribe_Errors(t *testing.T) {
+	t.Parallel()
+
+	type invalid struct {
+		Tag  int `env:"TAG,unknown"`
+		Type any `env:"TYPE"`
+	}
+
+	_, err := env.Describe(describeConfig{})
+	require.ErrorIs(t, err, env.ErrNotPtr)
+
+	_, err = env.Describe(&invalid{})
+	require.ErrorIs(t, err, env.ErrFieldTag)
+	require.ErrorIs(t, err, env.ErrFieldUnsupported)
+}
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, env.Usage(&buf, &describeConfig{}))
+	require.Equal(t, ""+
+		"VARIABLE     TYPE           DEFAULT   DESCRIPTION\n"+
+		"DB_HOST      string         required  database host (or DB_HOST_FILE)\n"+
+		"DB_PASSWORD  string         required  database password (secret, or DB_PASSWORD_FILE)\n"+
+		"PORT         int            8080      listening port\n"+
+		"TIMEOUT      time.Duration  5s        (or TIMEOUT_FILE)\n"+
+		"TOKEN        string         ***       (secret, or TOKEN_FILE)\n",
+		buf.String())
+}
+
+func TestReadStruct_NoFile(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Port int `env:"PORT,nofile"`
+	}
+	src := env.MapSource(map[string]string{"PORT_FILE": "/port"}, nil)
+
+	var cfg config
+	require.NoError(t, env.ReadStruct(&cfg, env.WithSource(src)))
+	require.Zero(t, cfg.Port)
+}
@@ -0,0 +1,375 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DotenvMode defines the precedence of a dotenv file relative to its base
+// source
+type DotenvMode int
+
+const (
+	// DotenvFill only provides the variables the base source does not set
+	DotenvFill DotenvMode = iota
+	// DotenvOverride provides every variable of the file, the base source only
+	// provides the others
+	DotenvOverride
+	// DotenvStrict fails when a variable is set by both the file and the base
+	// source
+	DotenvStrict
+)
+
+// DotenvSource returns a source layering the dotenv file at {path} over
+// {base} according to {mode}. `_FILE` paths are read from the file system of
+// {base}. References in the file are resolved against its previous entries,
+// then against {base}. {opts} apply to the references and the detection of
+// conflicts, their source is ignored.
+func DotenvSource(path string, base Source, mode DotenvMode, opts ...Option) (Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	o := newOptions(opts)
+	o.source = base
+	vars, err := parseDotenv(f, o)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	dotenv := MapSource(vars, base.FS())
+	src := &dotenvSource{path: path, base: base, mode: mode, opts: opts}
+
+	switch mode {
+	case DotenvFill:
+		src.layers = layers{base, dotenv}
+	case DotenvOverride:
+		src.layers = layers{dotenv, base}
+	case DotenvStrict:
+		for key := range vars {
+			if o.sets(base, strings.TrimSuffix(key, o.files.suffix()), true) {
+				return nil, fmt.Errorf("%s: %w: %s", path, ErrDotenvConflict, key)
+			}
+		}
+		src.layers = layers{base, dotenv}
+	default:
+		return nil, fmt.Errorf("unknown dotenv mode %d", mode)
+	}
+	return src, nil
+}
+
+// dotenvSource is a dotenv file layered over its base source, it is reloaded
+// by watchers when the file changes
+type dotenvSource struct {
+	layers
+	path string
+	base Source
+	mode DotenvMode
+	opts []Option
+}
+
+func (s *dotenvSource) watchPaths() []string {
+	paths := []string{s.path}
+	if r, ok := s.base.(reloader); ok {
+		paths = append(paths, r.watchPaths()...)
+	}
+	return paths
+}
+
+func (s *dotenvSource) reload() (Source, error) {
+	base := s.base
+	if r, ok := base.(reloader); ok {
+		var err error
+		if base, err = r.reload(); err != nil {
+			return nil, err
+		}
+	}
+	return DotenvSource(s.path, base, s.mode, s.opts...)
+}
+
+// ParseDotenv parses the dotenv content from {r}. It supports :
+//   - blank lines and `#` comments, including at the end of unquoted values
+//   - an optional `export` prefix
+//   - unquoted values, with surrounding whitespace trimmed
+//   - single-quoted values, kept literally
+//   - double-quoted values, with `\n`, `\r`, `\t`, `\"`, `\\` and `\$` escapes
+//   - quoted values spanning several lines
+//   - `${VAR}` and `$VAR` references in unquoted and double-quoted values,
+//     resolved against the previous entries then the source, Read included.
+//     Unresolved references are replaced with an empty string.
+func ParseDotenv(r io.Reader, opts ...Option) (map[string]string, error) {
+	return parseDotenv(r, newOptions(opts))
+}
+
+func parseDotenv(r io.Reader, o *options) (map[string]string, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &dotenvParser{
+		src:  strings.ReplaceAll(string(raw), "\r\n", "\n"),
+		line: 1,
+		vars: make(map[string]string),
+		opts: o,
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.vars, nil
+}
+
+type dotenvParser struct {
+	src  string
+	pos  int
+	line int
+	vars map[string]string
+	opts *options
+}
+
+func (p *dotenvParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: line %d: %s", ErrDotenvSyntax, p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *dotenvParser) eof() bool  { return p.pos >= len(p.src) }
+func (p *dotenvParser) peek() byte { return p.src[p.pos] }
+
+func (p *dotenvParser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+// skipSpaces skips spaces and tabs, not newlines
+func (p *dotenvParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipLine skips up to and including the next newline
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+func (p *dotenvParser) parse() error {
+	for !p.eof() {
+		p.skipSpaces()
+		if p.eof() {
+			break
+		}
+		switch p.peek() {
+		case '\n':
+			p.next()
+			continue
+		case '#':
+			p.skipLine()
+			continue
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		p.vars[key] = value
+	}
+	return nil
+}
+
+func (p *dotenvParser) parseKey() (string, error) {
+	key := p.readName()
+	if key == "export" && !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skipSpaces()
+		key = p.readName()
+	}
+	if key == "" {
+		return "", p.errorf("invalid key")
+	}
+
+	p.skipSpaces()
+	if p.eof() || p.peek() != '=' {
+		return "", p.errorf("missing '=' after %s", key)
+	}
+	p.next()
+	p.skipSpaces()
+	return key, nil
+}
+
+// readName reads a variable name: letters, digits, '_' and '.' not starting
+// with a digit
+func (p *dotenvParser) readName() string {
+	start := p.pos
+	for !p.eof() && isNameChar(p.peek(), p.pos == start) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isNameChar(c byte, first bool) bool {
+	switch {
+	case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return true
+	case c == '.' || (c >= '0' && c <= '9'):
+		return !first
+	}
+	return false
+}
+
+func (p *dotenvParser) parseValue() (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+
+	switch p.peek() {
+	case '\'':
+		value, err := p.parseSingleQuoted()
+		if err != nil {
+			return "", err
+		}
+		return value, p.parseEndOfLine()
+	case '"':
+		value, err := p.parseDoubleQuoted()
+		if err != nil {
+			return "", err
+		}
+		return value, p.parseEndOfLine()
+	}
+
+	// unquoted: up to the end of line or a comment preceded by whitespace
+	start := p.pos
+	for !p.eof() && p.peek() != '\n' {
+		if p.peek() == '#' && p.pos > start && (p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+			break
+		}
+		p.pos++
+	}
+	value := strings.TrimSpace(p.src[start:p.pos])
+	p.skipLine()
+	return p.interpolate(value), nil
+}
+
+// parseEndOfLine only accepts whitespace and a comment after a quoted value
+func (p *dotenvParser) parseEndOfLine() error {
+	p.skipSpaces()
+	if p.eof() {
+		return nil
+	}
+	if c := p.peek(); c != '\n' && c != '#' {
+		return p.errorf("unexpected %q after quoted value", c)
+	}
+	p.skipLine()
+	return nil
+}
+
+func (p *dotenvParser) parseSingleQuoted() (string, error) {
+	line := p.line
+	p.next()
+	start := p.pos
+	for !p.eof() {
+		if p.peek() == '\'' {
+			value := p.src[start:p.pos]
+			p.next()
+			return value, nil
+		}
+		p.next()
+	}
+	p.line = line
+	return "", p.errorf("unterminated single-quoted value")
+}
+
+func (p *dotenvParser) parseDoubleQuoted() (string, error) {
+	line := p.line
+	p.next()
+
+	var b strings.Builder
+	for !p.eof() {
+		c := p.next()
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '$':
+			b.WriteString(p.reference())
+		case '\\':
+			if p.eof() {
+				continue
+			}
+			switch e := p.next(); e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(e)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	p.line = line
+	return "", p.errorf("unterminated double-quoted value")
+}
+
+// reference reads a `${VAR}` or `$VAR` reference right after its '$' and
+// returns its value. A '$' not followed by a name is kept as is.
+func (p *dotenvParser) reference() string {
+	if !p.eof() && p.peek() == '{' {
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end < 0 {
+			return "$"
+		}
+		name := p.src[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+		return p.resolve(name)
+	}
+
+	name := p.readName()
+	if name == "" {
+		return "$"
+	}
+	return p.resolve(name)
+}
+
+// interpolate replaces the references of an unquoted value
+func (p *dotenvParser) interpolate(value string) string {
+	if !strings.Contains(value, "$") {
+		return value
+	}
+	sub := &dotenvParser{src: value, line: p.line, vars: p.vars, opts: p.opts}
+
+	var b strings.Builder
+	for !sub.eof() {
+		c := sub.next()
+		if c == '$' {
+			b.WriteString(sub.reference())
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// resolve returns the value of the variable {name} from the previous entries
+// or the source
+func (p *dotenvParser) resolve(name string) string {
+	if v, ok := p.vars[name]; ok {
+		return v
+	}
+	v, _ := p.opts.read(name)
+	return v
+}
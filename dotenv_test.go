@@ -0,0 +1,198 @@
+package env_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+func TestParseDotenv(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name   string
+		input  string
+		expect map[string]string
+		err    error
+	}{
+		{
+			name:   "empty",
+			input:  "",
+			expect: map[string]string{},
+		},
+		{
+			name:   "comments and blank lines",
+			input:  "# comment\n\n  # indented comment\nKEY=value\n",
+			expect: map[string]string{"KEY": "value"},
+		},
+		{
+			name:   "unquoted values are trimmed",
+			input:  "KEY =  some value  \nOTHER=",
+			expect: map[string]string{"KEY": "some value", "OTHER": ""},
+		},
+		{
+			name:   "inline comment",
+			input:  "KEY=value # comment\nHASH=a#b",
+			expect: map[string]string{"KEY": "value", "HASH": "a#b"},
+		},
+		{
+			name:   "export prefix",
+			input:  "export KEY=value\nexport=other",
+			expect: map[string]string{"KEY": "value", "export": "other"},
+		},
+		{
+			name:   "single quotes are literal",
+			input:  `KEY='a \n ${B} # c'`,
+			expect: map[string]string{"KEY": `a \n ${B} # c`},
+		},
+		{
+			name:   "double quotes escapes",
+			input:  `KEY="a\nb\tc \"d\" \\ \$E \x"`,
+			expect: map[string]string{"KEY": "a\nb\tc \"d\" \\ $E \\x"},
+		},
+		{
+			name:   "multiline values",
+			input:  "KEY=\"line1\nline2\"\nCERT='-----BEGIN-----\nabc\n-----END-----'\nNEXT=ok",
+			expect: map[string]string{"KEY": "line1\nline2", "CERT": "-----BEGIN-----\nabc\n-----END-----", "NEXT": "ok"},
+		},
+		{
+			name:   "interpolation",
+			input:  "USER=admin\nHOST=db\nURL=postgres://${USER}@$HOST/app\nQUOTED=\"${USER}:${MISSING}\"",
+			expect: map[string]string{"USER": "admin", "HOST": "db", "URL": "postgres://admin@db/app", "QUOTED": "admin:"},
+		},
+		{
+			name:   "crlf line endings",
+			input:  "A=1\r\nB=2\r\n",
+			expect: map[string]string{"A": "1", "B": "2"},
+		},
+		{
+			name:  "missing equal",
+			input: "KEY value",
+			err:   env.ErrDotenvSyntax,
+		},
+		{
+			name:  "invalid key",
+			input: "1KEY=value",
+			err:   env.ErrDotenvSyntax,
+		},
+		{
+			name:  "unterminated quote",
+			input: "KEY=\"value\nOTHER=1",
+			err:   env.ErrDotenvSyntax,
+		},
+		{
+			name:  "trailing characters after quote",
+			input: `KEY="value" other`,
+			err:   env.ErrDotenvSyntax,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := env.ParseDotenv(strings.NewReader(tc.input), env.WithSource(env.MapSource(nil, nil)))
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, got)
+		})
+	}
+}
+
+func TestParseDotenv_SourceReferences(t *testing.T) {
+	t.Parallel()
+
+	src := env.MapSource(map[string]string{
+		"HOST":          "db",
+		"PASSWORD_FILE": "/run/secrets/password",
+	}, fstest.MapFS{"run/secrets/password": {Data: []byte("secret")}})
+
+	got, err := env.ParseDotenv(strings.NewReader("URL=${PASSWORD}@${HOST}"), env.WithSource(src))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"URL": "secret@db"}, got)
+}
+
+func TestDotenvSource(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("HOST=localhost\nPORT=5432\nPASSWORD=dev\n"), 0o600))
+
+	base := env.MapSource(map[string]string{
+		"HOST":          "db",
+		"PASSWORD_FILE": "/run/secrets/password",
+	}, fstest.MapFS{"run/secrets/password": {Data: []byte("secret")}})
+
+	tt := []struct {
+		name   string
+		mode   env.DotenvMode
+		expect map[string]string
+		err    error
+	}{
+		{
+			name:   "fill",
+			mode:   env.DotenvFill,
+			expect: map[string]string{"HOST": "db", "PORT": "5432", "PASSWORD": "secret"},
+		},
+		{
+			name:   "override",
+			mode:   env.DotenvOverride,
+			expect: map[string]string{"HOST": "localhost", "PORT": "5432", "PASSWORD": "dev"},
+		},
+		{
+			name: "strict",
+			mode: env.DotenvStrict,
+			err:  env.ErrDotenvConflict,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			src, err := env.DotenvSource(path, base, tc.mode)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			for key, expect := range tc.expect {
+				got, ok := env.Read(key, env.WithSource(src))
+				require.True(t, ok, key)
+				require.Equal(t, expect, got, key)
+			}
+		})
+	}
+
+	t.Run("strict without conflict", func(t *testing.T) {
+		t.Parallel()
+
+		src, err := env.DotenvSource(path, env.MapSource(map[string]string{"OTHER": "1"}, nil), env.DotenvStrict)
+		require.NoError(t, err)
+
+		type config struct {
+			Host  string `env:"HOST"`
+			Port  int    `env:"PORT"`
+			Other int    `env:"OTHER"`
+		}
+		var cfg config
+		require.NoError(t, env.ReadStruct(&cfg, env.WithSource(src)))
+		require.Equal(t, config{Host: "localhost", Port: 5432, Other: 1}, cfg)
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := env.DotenvSource(filepath.Join(t.TempDir(), "missing"), base, env.DotenvFill)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
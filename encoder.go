@@ -0,0 +1,228 @@
+package env
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EncoderFn encodes a value of a specific type into its string form, the
+// inverse of a DecoderFn
+type EncoderFn func(v any) (string, error)
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[reflect.Type]EncoderFn{
+		reflect.TypeFor[string]():        func(v any) (string, error) { return v.(string), nil },
+		reflect.TypeFor[[]uint8]():       func(v any) (string, error) { return string(v.([]byte)), nil }, // []byte
+		reflect.TypeFor[int]():           func(v any) (string, error) { return strconv.FormatInt(int64(v.(int)), 10), nil },
+		reflect.TypeFor[int8]():          func(v any) (string, error) { return strconv.FormatInt(int64(v.(int8)), 10), nil },
+		reflect.TypeFor[int16]():         func(v any) (string, error) { return strconv.FormatInt(int64(v.(int16)), 10), nil },
+		reflect.TypeFor[int32]():         func(v any) (string, error) { return strconv.FormatInt(int64(v.(int32)), 10), nil },
+		reflect.TypeFor[int64]():         func(v any) (string, error) { return strconv.FormatInt(v.(int64), 10), nil },
+		reflect.TypeFor[uint]():          func(v any) (string, error) { return strconv.FormatUint(uint64(v.(uint)), 10), nil },
+		reflect.TypeFor[uint8]():         func(v any) (string, error) { return strconv.FormatUint(uint64(v.(uint8)), 10), nil },
+		reflect.TypeFor[uint16]():        func(v any) (string, error) { return strconv.FormatUint(uint64(v.(uint16)), 10), nil },
+		reflect.TypeFor[uint32]():        func(v any) (string, error) { return strconv.FormatUint(uint64(v.(uint32)), 10), nil },
+		reflect.TypeFor[uint64]():        func(v any) (string, error) { return strconv.FormatUint(v.(uint64), 10), nil },
+		reflect.TypeFor[float32]():       func(v any) (string, error) { return strconv.FormatFloat(float64(v.(float32)), 'g', -1, 32), nil },
+		reflect.TypeFor[float64]():       func(v any) (string, error) { return strconv.FormatFloat(v.(float64), 'g', -1, 64), nil },
+		reflect.TypeFor[bool]():          func(v any) (string, error) { return strconv.FormatBool(v.(bool)), nil },
+		reflect.TypeFor[time.Time]():     func(v any) (string, error) { return v.(time.Time).Format(time.RFC3339Nano), nil },
+		reflect.TypeFor[time.Duration](): func(v any) (string, error) { return v.(time.Duration).String(), nil },
+		reflect.TypeFor[slog.Level]():    func(v any) (string, error) { return FormatLevel(v.(slog.Level)) },
+	}
+)
+
+// FormatLevel encodes a slog.Level into the lowercase name decoded by
+// ParseLevel. Levels between the named ones cannot be encoded.
+func FormatLevel(level slog.Level) (string, error) {
+	switch level {
+	case slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError:
+		return strings.ToLower(level.String()), nil
+	}
+	return "", fmt.Errorf("cannot encode slog.Level %s", level)
+}
+
+// RegisterEncoder registers the encoder of the type {t} for every subsequent
+// call, it replaces any encoder already registered for that type. It is safe
+// for concurrent use. Use WithEncoder to override an encoder for a single call
+// without altering the registry.
+func RegisterEncoder(t reflect.Type, fn EncoderFn) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[t] = fn
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// encoder returns the encoder of the type {t}, in order of precedence:
+//   - the per-call encoders
+//   - the registry
+//   - encoding.TextMarshaler then flag.Value, implemented by the type or a
+//     pointer to it
+//   - for named slice types, the encoder of their unnamed counterpart
+func (o *options) encoder(t reflect.Type) (EncoderFn, bool) {
+	if fn, ok := o.encoders[t]; ok {
+		return fn, true
+	}
+
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	if fn, ok := encoders[t]; ok {
+		return fn, true
+	}
+	if reflect.PointerTo(t).Implements(textMarshalerType) {
+		return textMarshalerEncoder, true
+	}
+	if reflect.PointerTo(t).Implements(flagValueType) {
+		return flagValueEncoder, true
+	}
+	if t.Kind() == reflect.Slice && t.Name() != "" {
+		if fn, ok := encoders[reflect.SliceOf(t.Elem())]; ok {
+			return convertEncoder(fn, reflect.SliceOf(t.Elem())), true
+		}
+	}
+	return nil, false
+}
+
+// fieldEncoder returns the encoder of a field of type {t}: the encoder of the
+// type itself, or an encoder built from the field {tag} for secrets, slices,
+// arrays and maps
+func (o *options) fieldEncoder(t reflect.Type, tag tag) (EncoderFn, bool) {
+	if fn, ok := o.encoder(t); ok {
+		return fn, true
+	}
+	if valueType, ok := secretValueType(t); ok {
+		valueEncoder, ok := o.fieldEncoder(valueType, tag)
+		if !ok {
+			return nil, false
+		}
+		return func(v any) (string, error) { return valueEncoder(unwrapSecret(v)) }, true
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return o.listEncoder(t, tag)
+	case reflect.Map:
+		return o.mapEncoder(t, tag)
+	}
+	return nil, false
+}
+
+// listEncoder encodes slices and arrays into items separated by {tag.sep},
+// items are quoted when needed so that SplitList reads them back
+func (o *options) listEncoder(t reflect.Type, tag tag) (EncoderFn, bool) {
+	elemEncoder, ok := o.encoder(t.Elem())
+	if !ok {
+		return nil, false
+	}
+
+	sep := tag.separator()
+	return func(v any) (string, error) {
+		list := reflect.ValueOf(v)
+		items := make([]string, list.Len())
+		for i := range items {
+			item, err := elemEncoder(list.Index(i).Interface())
+			if err != nil {
+				return "", fmt.Errorf("item %d: %w", i, err)
+			}
+			items[i] = quoteItem(item, sep, len(items) == 1)
+		}
+		return strings.Join(items, sep), nil
+	}, true
+}
+
+// mapEncoder encodes maps into entries separated by {tag.sep}, made of a key
+// and a value separated by {tag.kvsep}. Sets only contain keys. Entries are
+// sorted for the output to be stable.
+func (o *options) mapEncoder(t reflect.Type, tag tag) (EncoderFn, bool) {
+	keyEncoder, ok := o.encoder(t.Key())
+	if !ok {
+		return nil, false
+	}
+
+	isSet := t.Elem().Kind() == reflect.Struct && t.Elem().NumField() == 0
+	valueEncoder, ok := o.encoder(t.Elem())
+	if !ok && !isSet {
+		return nil, false
+	}
+
+	sep, kvsep := tag.separator(), tag.kvSeparator()
+	return func(v any) (string, error) {
+		var entries []string
+		iter := reflect.ValueOf(v).MapRange()
+		for iter.Next() {
+			key, err := keyEncoder(iter.Key().Interface())
+			if err != nil {
+				return "", fmt.Errorf("map key: %w", err)
+			}
+			if strings.Contains(key, kvsep) || key != strings.TrimSpace(key) {
+				return "", fmt.Errorf("cannot encode map key %q", key)
+			}
+			entry := key
+			if !isSet {
+				value, err := valueEncoder(iter.Value().Interface())
+				if err != nil {
+					return "", fmt.Errorf("map value of %q: %w", key, err)
+				}
+				if value != strings.TrimSpace(value) {
+					return "", fmt.Errorf("cannot encode map value %q", value)
+				}
+				entry += kvsep + value
+			}
+			entries = append(entries, quoteItem(entry, sep, false))
+		}
+		slices.Sort(entries)
+		return strings.Join(entries, sep), nil
+	}, true
+}
+
+// quoteItem double quotes the list {item} when SplitList would not read it
+// back as is: it contains {sep}, quotes, backslashes or surrounding
+// whitespace. An empty {single} item is quoted not to read an empty list.
+func quoteItem(item, sep string, single bool) string {
+	needsQuotes := strings.Contains(item, sep) ||
+		strings.ContainsAny(item, `"'\`) ||
+		item != strings.TrimSpace(item) ||
+		(single && item == "")
+	if !needsQuotes {
+		return item
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(item) + `"`
+}
+
+// convertEncoder encodes values converted to the type {t} with {fn}
+func convertEncoder(fn EncoderFn, t reflect.Type) EncoderFn {
+	return func(v any) (string, error) {
+		return fn(reflect.ValueOf(v).Convert(t).Interface())
+	}
+}
+
+// addressable returns a pointer to a copy of {v}, for methods implemented by
+// the pointer
+func addressable(v any) reflect.Value {
+	rv := reflect.ValueOf(v)
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	return ptr
+}
+
+// textMarshalerEncoder encodes values through the encoding.TextMarshaler
+// implemented by the type or a pointer to it
+func textMarshalerEncoder(v any) (string, error) {
+	text, err := addressable(v).Interface().(encoding.TextMarshaler).MarshalText()
+	return string(text), err
+}
+
+// flagValueEncoder encodes values through the flag.Value implemented by a
+// pointer to the type
+func flagValueEncoder(v any) (string, error) {
+	return addressable(v).Interface().(flag.Value).String(), nil
+}
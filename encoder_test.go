@@ -0,0 +1,58 @@
+package env_test
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+type accountID int
+
+func TestRegisterEncoder(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Account  accountID   `env:"ACCOUNT"`
+		Accounts []accountID `env:"ACCOUNTS"`
+	}
+	env.RegisterEncoder(reflect.TypeFor[accountID](), func(v any) (string, error) {
+		return fmt.Sprintf("acc-%d", v.(accountID)), nil
+	})
+	env.RegisterDecoder(reflect.TypeFor[accountID](), func(raw string) (any, error) {
+		var id accountID
+		_, err := fmt.Sscanf(raw, "acc-%d", &id)
+		return id, err
+	})
+
+	cfg := config{Account: 1, Accounts: []accountID{2, 3}}
+	environ, err := env.Marshal(cfg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ACCOUNT=acc-1", "ACCOUNTS=acc-2,acc-3"}, environ)
+
+	// per-call encoders take precedence
+	environ, err = env.Marshal(cfg, env.WithEncoder(reflect.TypeFor[accountID](), func(v any) (string, error) {
+		return strings.Repeat("x", int(v.(accountID))), nil
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"ACCOUNT=x", "ACCOUNTS=xx,xxx"}, environ)
+}
+
+func TestFormatLevel(t *testing.T) {
+	t.Parallel()
+
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+		raw, err := env.FormatLevel(level)
+		require.NoError(t, err)
+		decoded, err := env.ParseLevel(raw)
+		require.NoError(t, err)
+		require.Equal(t, level, decoded)
+	}
+
+	_, err := env.FormatLevel(slog.LevelInfo + 1)
+	require.Error(t, err)
+}
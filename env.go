@@ -1,26 +1,206 @@
 package env
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
 )
 
+// OriginKind is the kind of place a value comes from
+type OriginKind int
+
+// avail origin kinds
+const (
+	OriginUnset OriginKind = iota
+	OriginEnv
+	OriginFile
+	// OriginDefault is the `default` tag option of a field, it is never
+	// returned by Lookup
+	OriginDefault
+)
+
+func (k OriginKind) String() string {
+	switch k {
+	case OriginEnv:
+		return "env"
+	case OriginFile:
+		return "file"
+	case OriginDefault:
+		return "default"
+	default:
+		return "unset"
+	}
+}
+
+// Origin describes where a value comes from
+type Origin struct {
+	Kind OriginKind
+	// Key is the variable that provided the value: the key itself or its
+	// `_FILE` variant
+	Key string
+	// Path is the file the value has been read from, for OriginFile only
+	Path string
+}
+
 // Read returns :
 //   - the value of the environment variable {key} if it exists
 //   - the contents of the file located at the path from the environment variable
 //     {key}_FILE if it exists
-func Read(key string) (string, bool) {
-	if raw, ok := os.LookupEnv(key); ok {
-		return raw, true
+//
+// Variables are read from the process environment unless another Source is
+// provided with WithSource. A `_FILE` variable that cannot be resolved is
+// considered unset, use ReadE or Lookup to get the error.
+func Read(key string, opts ...Option) (string, bool) {
+	return newOptions(opts).read(key)
+}
+
+// ReadE is Read distinguishing an unset variable from a broken one: it fails
+// with ErrFileNotFound or ErrFileUnreadable when the path from {key}_FILE
+// cannot be read.
+func ReadE(key string, opts ...Option) (string, bool, error) {
+	raw, origin, err := newOptions(opts).lookup(key)
+	if err != nil {
+		return "", false, err
 	}
+	return raw, origin.Kind != OriginUnset, nil
+}
 
-	path, ok := os.LookupEnv(key + "_FILE")
-	if !ok {
+// Lookup returns the value of the variable {key} like ReadE, along with where
+// it comes from. An unset variable has an OriginUnset origin and no error, a
+// `_FILE` variant that cannot be read has the origin of the attempted file.
+func Lookup(key string, opts ...Option) (string, Origin, error) {
+	return newOptions(opts).lookup(key)
+}
+
+func (o *options) read(key string) (string, bool) {
+	raw, origin, err := o.lookup(key)
+	if err != nil || origin.Kind == OriginUnset {
 		return "", false
 	}
+	return raw, true
+}
+
+func (o *options) lookup(key string) (string, Origin, error) {
+	return o.lookupSource(o.source, key, true)
+}
+
+// lookupSource reads the variable {key} from {src}, or its `_FILE` variant
+// when {files} is set. For layered sources, the first layer that sets either
+// of them wins.
+func (o *options) lookupSource(src Source, key string, files bool) (string, Origin, error) {
+	origin, src := o.locate(src, key, files)
+	switch origin.Kind {
+	case OriginEnv:
+		raw, _ := src.Lookup(key)
+		return raw, origin, nil
+	case OriginFile:
+		if o.onFile != nil {
+			o.onFile(src.FS(), origin.Path)
+		}
+		raw, err := o.files.read(src.FS(), origin.Path)
+		if err != nil {
+			if o.files.MissingIsUnset && errors.Is(err, ErrFileNotFound) {
+				return "", Origin{}, nil
+			}
+			return "", origin, fmt.Errorf("%s: %w", origin.Key, err)
+		}
+		return raw, origin, nil
+	}
+	return "", Origin{}, nil
+}
+
+// locate returns where lookupSource reads the variable {key} from, without
+// reading files, along with the source that sets it
+func (o *options) locate(src Source, key string, files bool) (Origin, Source) {
+	if l, ok := src.(layered); ok {
+		for _, layer := range l.sources() {
+			if o.sets(layer, key, files) {
+				return o.locate(layer, key, files)
+			}
+		}
+		return Origin{}, src
+	}
+
+	if _, ok := src.Lookup(key); ok {
+		return Origin{Kind: OriginEnv, Key: key}, src
+	}
+	if !files {
+		return Origin{}, src
+	}
+	fileKey := key + o.files.suffix()
+	if path, ok := src.Lookup(fileKey); ok {
+		return Origin{Kind: OriginFile, Key: fileKey, Path: path}, src
+	}
+	return Origin{}, src
+}
+
+// sets returns whether {src} sets the variable {key}, or its `_FILE` variant
+// when {files} is set
+func (o *options) sets(src Source, key string, files bool) bool {
+	if _, ok := src.Lookup(key); ok {
+		return true
+	}
+	if !files {
+		return false
+	}
+	_, ok := src.Lookup(key + o.files.suffix())
+	return ok
+}
+
+// DefaultFileSuffix is the suffix of the variables holding a file path
+const DefaultFileSuffix = "_FILE"
 
-	raw, err := os.ReadFile(path)
+// FilePolicy defines how `_FILE` variables are resolved
+type FilePolicy struct {
+	// Suffix appended to a key to get its file variable, DefaultFileSuffix
+	// when empty
+	Suffix string
+	// TrimSpace removes the trailing whitespace of the file content, e.g. the
+	// trailing newline of most secret files
+	TrimSpace bool
+	// MissingIsUnset considers the variable unset when the file does not
+	// exist, instead of failing with ErrFileNotFound. Other read errors are
+	// always reported.
+	MissingIsUnset bool
+	// MaxSize fails with ErrFileTooLarge when the file is larger than MaxSize
+	// bytes, 0 for no limit
+	MaxSize int64
+}
+
+func (p FilePolicy) suffix() string {
+	if p.Suffix == "" {
+		return DefaultFileSuffix
+	}
+	return p.Suffix
+}
+
+// read reads the file at {path} from {fsys}
+func (p FilePolicy) read(fsys fs.FS, path string) (string, error) {
+	f, err := fsys.Open(fsPath(fsys, path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: %w", ErrFileNotFound, err)
+	}
 	if err != nil {
-		return "", false
+		return "", fmt.Errorf("%w: %w", ErrFileUnreadable, err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if p.MaxSize > 0 {
+		r = io.LimitReader(f, p.MaxSize+1)
+	}
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", ErrFileUnreadable, path, err)
+	}
+	if p.MaxSize > 0 && int64(len(raw)) > p.MaxSize {
+		return "", fmt.Errorf("%w: %s exceeds %d bytes", ErrFileTooLarge, path, p.MaxSize)
+	}
+
+	if p.TrimSpace {
+		return strings.TrimRight(string(raw), " \t\r\n"), nil
 	}
-	return string(raw), true
+	return string(raw), nil
 }
@@ -2,7 +2,9 @@ package env_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
 	"github.com/xdrm-io/env"
@@ -89,3 +91,178 @@ func TestRead(t *testing.T) {
 		})
 	}
 }
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	files := fstest.MapFS{
+		"run/secrets/password": {Data: []byte("secret\n")},
+		"run/secrets/large":    {Data: []byte("0123456789")},
+	}
+
+	tt := []struct {
+		name   string
+		vars   map[string]string
+		policy env.FilePolicy
+		expect string
+		origin env.Origin
+		err    error
+	}{
+		{
+			name:   "env",
+			vars:   map[string]string{"KEY": "value"},
+			expect: "value",
+			origin: env.Origin{Kind: env.OriginEnv, Key: "KEY"},
+		},
+		{
+			name:   "unset",
+			vars:   map[string]string{},
+			origin: env.Origin{Kind: env.OriginUnset},
+		},
+		{
+			name:   "file keeps trailing newline",
+			vars:   map[string]string{"KEY_FILE": "/run/secrets/password"},
+			expect: "secret\n",
+			origin: env.Origin{Kind: env.OriginFile, Key: "KEY_FILE", Path: "/run/secrets/password"},
+		},
+		{
+			name:   "file trimmed",
+			vars:   map[string]string{"KEY_FILE": "/run/secrets/password"},
+			policy: env.FilePolicy{TrimSpace: true},
+			expect: "secret",
+			origin: env.Origin{Kind: env.OriginFile, Key: "KEY_FILE", Path: "/run/secrets/password"},
+		},
+		{
+			name:   "custom suffix",
+			vars:   map[string]string{"KEY_FILE": "/wrong", "KEY_PATH": "/run/secrets/password"},
+			policy: env.FilePolicy{Suffix: "_PATH", TrimSpace: true},
+			expect: "secret",
+			origin: env.Origin{Kind: env.OriginFile, Key: "KEY_PATH", Path: "/run/secrets/password"},
+		},
+		{
+			name: "missing file is an error",
+			vars: map[string]string{"KEY_FILE": "/run/secrets/missing"},
+			err:  env.ErrFileNotFound,
+		},
+		{
+			name:   "missing file is unset",
+			vars:   map[string]string{"KEY_FILE": "/run/secrets/missing"},
+			policy: env.FilePolicy{MissingIsUnset: true},
+			origin: env.Origin{Kind: env.OriginUnset},
+		},
+		{
+			name: "directory is unreadable",
+			vars: map[string]string{"KEY_FILE": "/run/secrets"},
+			err:  env.ErrFileUnreadable,
+		},
+		{
+			name:   "file within max size",
+			vars:   map[string]string{"KEY_FILE": "/run/secrets/large"},
+			policy: env.FilePolicy{MaxSize: 10},
+			expect: "0123456789",
+			origin: env.Origin{Kind: env.OriginFile, Key: "KEY_FILE", Path: "/run/secrets/large"},
+		},
+		{
+			name:   "file too large",
+			vars:   map[string]string{"KEY_FILE": "/run/secrets/large"},
+			policy: env.FilePolicy{MaxSize: 9},
+			err:    env.ErrFileTooLarge,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, origin, err := env.Lookup("KEY",
+				env.WithSource(env.MapSource(tc.vars, files)),
+				env.WithFilePolicy(tc.policy),
+			)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, got)
+			require.Equal(t, tc.origin, origin)
+		})
+	}
+}
+
+func TestReadStruct_FilePolicy(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Password string `env:"PASSWORD"`
+	}
+	src := env.MapSource(map[string]string{"PASSWORD_FILE": "/run/secrets/missing"}, nil)
+
+	var cfg config
+	err := env.ReadStruct(&cfg, env.WithSource(src))
+	require.ErrorIs(t, err, env.ErrFieldFile)
+	require.ErrorIs(t, err, env.ErrFileNotFound)
+	require.ErrorContains(t, err, "PASSWORD_FILE")
+
+	require.NoError(t, env.ReadStruct(&cfg, env.WithSource(src), env.WithFilePolicy(env.FilePolicy{MissingIsUnset: true})))
+}
+
+func TestReadE(t *testing.T) {
+	dir := t.TempDir()
+	unreadable := filepath.Join(dir, "unreadable")
+	require.NoError(t, os.WriteFile(unreadable, []byte("secret"), 0o000))
+	if _, err := os.ReadFile(unreadable); err == nil {
+		t.Skip("file permissions are not enforced")
+	}
+
+	tt := []struct {
+		name   string
+		vars   map[string]string
+		expect string
+		ok     bool
+		err    error
+	}{
+		{
+			name:   "set",
+			vars:   map[string]string{"KEY": "value"},
+			expect: "value",
+			ok:     true,
+		},
+		{
+			name: "unset",
+			vars: map[string]string{},
+		},
+		{
+			name: "file not found",
+			vars: map[string]string{"KEY_FILE": filepath.Join(dir, "missing")},
+			err:  env.ErrFileNotFound,
+		},
+		{
+			name: "file permission denied",
+			vars: map[string]string{"KEY_FILE": unreadable},
+			err:  env.ErrFileUnreadable,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			for k, v := range tc.vars {
+				os.Setenv(k, v)
+			}
+
+			got, ok, err := env.ReadE("KEY")
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				require.False(t, ok)
+
+				// Read considers the variable unset
+				_, ok = env.Read("KEY")
+				require.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, got)
+			require.Equal(t, tc.ok, ok)
+		})
+	}
+}
@@ -1,5 +1,10 @@
 package env
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Err is an error that can be returned by the env package
 type Err string
 
@@ -10,9 +15,75 @@ const (
 	ErrNotPtr       Err = "not a pointer"
 	ErrNotStructPtr Err = "not a pointer to struct"
 
+	ErrFileNotFound   Err = "file not found"
+	ErrFileTooLarge   Err = "file too large"
+	ErrFileUnreadable Err = "file unreadable"
+
 	ErrFieldUnexported  Err = "field is unexported"
 	ErrFieldNoEnvTag    Err = "no env tag"
 	ErrFieldDecode      Err = "field decode"
 	ErrFieldUnsupported Err = "unsupported field type"
 	ErrFieldRequired    Err = "field is required"
+	ErrFieldTag         Err = "invalid env tag"
+	ErrFieldDefault     Err = "invalid default value"
+	ErrFieldFile        Err = "cannot read field file"
+	ErrFieldEncode      Err = "field encode"
+
+	ErrFieldRange   Err = "value out of range"
+	ErrFieldLength  Err = "invalid length"
+	ErrFieldOneOf   Err = "value not allowed"
+	ErrFieldPattern Err = "value does not match pattern"
+	ErrFieldEmpty   Err = "value is empty"
+	ErrFieldInvalid Err = "invalid value"
+
+	ErrDotenvSyntax   Err = "invalid dotenv syntax"
+	ErrDotenvConflict Err = "dotenv variable conflicts with the environment"
+
+	ErrExpandSyntax Err = "invalid variable reference"
+	ErrExpandUnset  Err = "variable is not set"
+	ErrExpandCycle  Err = "variable references itself"
+
+	ErrWatchInterval Err = "watch interval must be positive"
 )
+
+// FieldError is the error of a single struct field
+type FieldError struct {
+	// Field is the path of the field, e.g. "DB.Host", empty outside of structs
+	Field string
+	// Key is the env key the field is bound to, empty when it is unknown
+	Key string
+	// Err is the cause, it wraps one of the Err constants
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.Key, e.Err)
+	}
+	if e.Key == "" {
+		return fmt.Sprintf("field %q: %s", e.Field, e.Err)
+	}
+	return fmt.Sprintf("field %q (%s): %s", e.Field, e.Key, e.Err)
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// Errors aggregates the errors of every failing field. It matches any of its
+// entries with errors.Is and errors.As.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,199 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expand replaces the references to variables in {s} with their values, read
+// like Read reads them, `_FILE` variants included. It follows the POSIX
+// parameter expansion :
+//   - `$VAR` and `${VAR}` are the value of VAR, empty when unset
+//   - `${VAR:-word}` is word when VAR is unset or empty, `${VAR-word}` when
+//     unset only ; `:=` and `=` behave alike as sources are read-only
+//   - `${VAR:+word}` is word when VAR is set and not empty, `${VAR+word}` when
+//     set
+//   - `${VAR:?msg}` fails with ErrExpandUnset and {msg} when VAR is unset or
+//     empty, `${VAR?msg}` when unset
+//   - `$$` is a literal `$`, a `$` followed by anything else is kept as is
+//
+// Words and the values of the referenced variables are expanded as well, a
+// variable referencing itself fails with ErrExpandCycle. Malformed references
+// fail with ErrExpandSyntax.
+func Expand(s string, opts ...Option) (string, error) {
+	return newOptions(opts).expand(s)
+}
+
+func (o *options) expand(s string, keys ...string) (string, error) {
+	e := &expander{opts: o, expanding: keys, values: map[string]string{}}
+	return e.expand(s)
+}
+
+// expander expands the references of a value
+type expander struct {
+	opts *options
+	// expanding are the variables being expanded, to detect cycles
+	expanding []string
+	// values caches the expanded value of the variables that are set
+	values map[string]string
+}
+
+func (e *expander) expand(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		switch c := s[i+1]; {
+		case c == '$':
+			b.WriteByte('$')
+			i += 2
+
+		case c == '{':
+			end, err := closingBrace(s, i+2)
+			if err != nil {
+				return "", err
+			}
+			value, err := e.parameter(s[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end + 1
+
+		case nameLen(s[i+1:]) > 0:
+			name := s[i+1 : i+1+nameLen(s[i+1:])]
+			value, _, err := e.variable(name)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i += 1 + len(name)
+
+		default:
+			b.WriteByte('$')
+			i++
+		}
+	}
+	return b.String(), nil
+}
+
+// parameter expands the content {expr} of a `${...}` reference
+func (e *expander) parameter(expr string) (string, error) {
+	n := nameLen(expr)
+	if n == 0 {
+		return "", fmt.Errorf("%w: bad substitution ${%s}", ErrExpandSyntax, expr)
+	}
+	name, rest := expr[:n], expr[n:]
+
+	value, set, err := e.variable(name)
+	if err != nil || rest == "" {
+		return value, err
+	}
+
+	colon := rest[0] == ':'
+	if colon {
+		rest = rest[1:]
+	}
+	if rest == "" || !strings.ContainsRune("-=+?", rune(rest[0])) {
+		return "", fmt.Errorf("%w: bad substitution ${%s}", ErrExpandSyntax, expr)
+	}
+	op, word := rest[0], rest[1:]
+	missing := !set || colon && value == ""
+
+	switch op {
+	case '+':
+		if missing {
+			return "", nil
+		}
+		return e.expand(word)
+	case '?':
+		if !missing {
+			return value, nil
+		}
+		msg, err := e.expand(word)
+		if err != nil {
+			return "", err
+		}
+		if msg == "" {
+			msg = "parameter null or not set"
+		}
+		return "", fmt.Errorf("%w: %s: %s", ErrExpandUnset, name, msg)
+	default:
+		if missing {
+			return e.expand(word)
+		}
+		return value, nil
+	}
+}
+
+// variable returns the expanded value of the variable {name} and whether it
+// is set
+func (e *expander) variable(name string) (string, bool, error) {
+	if value, ok := e.values[name]; ok {
+		return value, true, nil
+	}
+	for i, key := range e.expanding {
+		if key == name {
+			cycle := append(e.expanding[i:len(e.expanding):len(e.expanding)], name)
+			return "", false, fmt.Errorf("%w: %s", ErrExpandCycle, strings.Join(cycle, " -> "))
+		}
+	}
+
+	raw, ok := e.opts.read(name)
+	if !ok {
+		return "", false, nil
+	}
+
+	e.expanding = append(e.expanding, name)
+	value, err := e.expand(raw)
+	e.expanding = e.expanding[:len(e.expanding)-1]
+	if err != nil {
+		return "", false, err
+	}
+	e.values[name] = value
+	return value, true, nil
+}
+
+// closingBrace returns the index of the '}' closing the reference which
+// content starts at {start}, nested references included
+func closingBrace(s string, start int) (int, error) {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%w: unterminated ${", ErrExpandSyntax)
+}
+
+// nameLen returns the length of the variable name at the start of {s}, made
+// of letters, digits and underscores and not starting with a digit
+func nameLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return i
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,151 @@
+package env_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	src := env.MapSource(map[string]string{
+		"USER":          "admin",
+		"EMPTY":         "",
+		"HOST":          "db.local",
+		"PASSWORD_FILE": "/run/secrets/password",
+		"URL":           "postgres://${USER}@${HOST}",
+		"CYCLE_A":       "${CYCLE_B}",
+		"CYCLE_B":       "$CYCLE_A",
+		"SELF":          "x${SELF}",
+	}, fstest.MapFS{"run/secrets/password": {Data: []byte("p@ss")}})
+
+	tt := []struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}{
+		{name: "no reference", input: "plain", expected: "plain"},
+		{name: "simple", input: "$USER@$HOST.example", expected: "admin@db.local.example"},
+		{name: "braced", input: "${USER}_${HOST}", expected: "admin_db.local"},
+		{name: "file", input: "${USER}:${PASSWORD}", expected: "admin:p@ss"},
+		{name: "unset", input: "[$MISSING]", expected: "[]"},
+		{name: "nested", input: "url=$URL", expected: "url=postgres://admin@db.local"},
+		{name: "literal", input: "$$USER costs 5$ $", expected: "$USER costs 5$ $"},
+		{name: "not a name", input: "$1 $-", expected: "$1 $-"},
+
+		{name: "default unset", input: "${MISSING:-def}", expected: "def"},
+		{name: "default empty", input: "${EMPTY:-def}", expected: "def"},
+		{name: "default set", input: "${USER:-def}", expected: "admin"},
+		{name: "default empty no colon", input: "${EMPTY-def}", expected: ""},
+		{name: "default unset no colon", input: "${MISSING-def}", expected: "def"},
+		{name: "default assign", input: "${MISSING:=def}", expected: "def"},
+		{name: "default reference", input: "${MISSING:-${USER:-x}}", expected: "admin"},
+
+		{name: "alternate set", input: "${USER:+alt}", expected: "alt"},
+		{name: "alternate empty", input: "${EMPTY:+alt}", expected: ""},
+		{name: "alternate empty no colon", input: "${EMPTY+alt}", expected: "alt"},
+		{name: "alternate unset", input: "${MISSING+alt}", expected: ""},
+
+		{name: "error set", input: "${USER:?required}", expected: "admin"},
+		{name: "error empty no colon", input: "${EMPTY?required}", expected: ""},
+		{name: "error empty", input: "${EMPTY:?required}", err: env.ErrExpandUnset},
+		{name: "error unset", input: "${MISSING?}", err: env.ErrExpandUnset},
+
+		{name: "cycle", input: "${CYCLE_A}", err: env.ErrExpandCycle},
+		{name: "self", input: "$SELF", err: env.ErrExpandCycle},
+		{name: "unterminated", input: "${USER", err: env.ErrExpandSyntax},
+		{name: "bad name", input: "${-USER}", err: env.ErrExpandSyntax},
+		{name: "bad operator", input: "${USER#prefix}", err: env.ErrExpandSyntax},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := env.Expand(tc.input, env.WithSource(src))
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, value)
+		})
+	}
+}
+
+func TestExpand_Messages(t *testing.T) {
+	t.Parallel()
+
+	src := env.MapSource(map[string]string{"A": "${B}", "B": "${A}"}, nil)
+
+	_, err := env.Expand("${A}", env.WithSource(src))
+	require.EqualError(t, err, "variable references itself: A -> B -> A")
+
+	_, err = env.Expand("${HOST:?must be set}", env.WithSource(src))
+	require.EqualError(t, err, "variable is not set: HOST: must be set")
+
+	_, err = env.Expand("${HOST?}", env.WithSource(src))
+	require.EqualError(t, err, "variable is not set: HOST: parameter null or not set")
+}
+
+func TestReadStruct_Expand(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		URL     string `env:"DATABASE_URL,expand,required"`
+		Raw     string `env:"RAW"`
+		Port    int    `env:"PORT,expand,default=${DEFAULT_PORT:-5432}"`
+		Workers int    `env:"WORKERS,expand,max=64"`
+	}
+
+	src := env.MapSource(map[string]string{
+		"DATABASE_URL": "postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST}/app",
+		"DB_USER":      "admin",
+		"DB_PASSWORD":  "p@ss",
+		"DB_HOST_FILE": "/run/secrets/host",
+		"RAW":          "${DB_USER}",
+		"CPUS":         "8",
+		"WORKERS":      "${CPUS}",
+	}, fstest.MapFS{"run/secrets/host": {Data: []byte("db.local")}})
+
+	var cfg config
+	require.NoError(t, env.ReadStruct(&cfg, env.WithSource(src)))
+	require.Equal(t, config{
+		URL:     "postgres://admin:p@ss@db.local/app",
+		Raw:     "${DB_USER}",
+		Port:    5432,
+		Workers: 8,
+	}, cfg)
+}
+
+func TestReadStruct_ExpandErrors(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		URL     string `env:"URL,expand"`
+		Workers int    `env:"WORKERS,expand,max=64"`
+		Port    int    `env:"PORT,expand,default=${PORT_DEFAULT}"`
+	}
+
+	src := env.MapSource(map[string]string{
+		"URL":     "http://${URL}",
+		"CPUS":    "80",
+		"WORKERS": "${CPUS}",
+	}, nil)
+
+	var cfg config
+	err := env.ReadStruct(&cfg, env.WithSource(src))
+	require.ErrorIs(t, err, env.ErrExpandCycle)
+	require.ErrorIs(t, err, env.ErrFieldRange)
+	require.ErrorIs(t, err, env.ErrFieldDefault)
+
+	var errs env.Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 3)
+	require.Equal(t, "URL", errs[0].Key)
+	require.EqualError(t, errs[0].Err, "variable references itself: URL -> URL")
+}
@@ -0,0 +1,76 @@
+package env
+
+// Explanation describes where the value of a struct field comes from
+type Explanation struct {
+	// Field is the path of the field, e.g. "DB.Host"
+	Field string
+	// Key is the variable consulted, prefixes included
+	Key string
+	// Origin is where the value comes from: the variable or one of its
+	// aliases, the `_FILE` variant and its path, or the default. It is unset
+	// when the field has been left as is, it is the attempted `_FILE` variant
+	// when reading it fails.
+	Origin Origin
+	// Shadowed are the other candidates that are set but lost, by order of
+	// precedence, e.g. KEY_FILE when KEY is set too, the variables of lower
+	// layers, aliases, or the default
+	Shadowed []Origin
+	// Err is the error of the field, if any
+	Err error
+}
+
+// explain records the explanation of the field at {path} bound to {t}, its
+// keys prefixed by {prefix}, when explanations are requested
+func (r *structReader) explain(path, prefix string, t tag, origin Origin, err error) {
+	if r.opts.explain == nil {
+		return
+	}
+
+	var shadowed []Origin
+	won := false
+	for _, key := range t.keys(prefix) {
+		for _, candidate := range r.opts.candidates(r.opts.source, key, !t.noFile) {
+			if !won && candidate == origin {
+				won = true
+				continue
+			}
+			shadowed = append(shadowed, candidate)
+		}
+	}
+	if t.hasDefault && origin.Kind != OriginDefault {
+		shadowed = append(shadowed, Origin{Kind: OriginDefault})
+	}
+
+	r.explanations = append(r.explanations, Explanation{
+		Field:    path,
+		Key:      prefix + t.key,
+		Origin:   origin,
+		Shadowed: shadowed,
+		Err:      err,
+	})
+}
+
+// candidates returns every origin {src} could read the variable {key} from,
+// by order of precedence, without reading files
+func (o *options) candidates(src Source, key string, files bool) []Origin {
+	if l, ok := src.(layered); ok {
+		var origins []Origin
+		for _, layer := range l.sources() {
+			origins = append(origins, o.candidates(layer, key, files)...)
+		}
+		return origins
+	}
+
+	var origins []Origin
+	if _, ok := src.Lookup(key); ok {
+		origins = append(origins, Origin{Kind: OriginEnv, Key: key})
+	}
+	if !files {
+		return origins
+	}
+	fileKey := key + o.files.suffix()
+	if path, ok := src.Lookup(fileKey); ok {
+		origins = append(origins, Origin{Kind: OriginFile, Key: fileKey, Path: path})
+	}
+	return origins
+}
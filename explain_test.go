@@ -0,0 +1,128 @@
+package env_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+func TestReadStruct_Explain(t *testing.T) {
+	t.Parallel()
+
+	type db struct {
+		DSN string `env:"DSN,required"`
+	}
+	type config struct {
+		DB      db     `envPrefix:"DB_"`
+		Port    int    `env:"PORT,default=8080"`
+		Host    string `env:"HOST,default=localhost"`
+		Token   string `env:"TOKEN"`
+		Debug   bool   `env:"DEBUG"`
+		Timeout int    `env:"TIMEOUT,nofile"`
+		Name    string `env:"NAME,required"`
+	}
+	src := env.Layers(
+		env.MapSource(map[string]string{
+			"DB_DSN":      "postgres://prod",
+			"DB_DSN_FILE": "/run/secrets/dsn",
+			"HOST":        "example.com",
+		}, nil),
+		env.MapSource(map[string]string{
+			"DB_DSN":     "postgres://dev",
+			"TOKEN_FILE": "/run/secrets/token",
+			"PORT":       "invalid",
+		}, fstest.MapFS{"run/secrets/token": {Data: []byte("t0k3n")}}),
+	)
+
+	var (
+		cfg       config
+		explained []env.Explanation
+	)
+	err := env.ReadStruct(&cfg, env.WithSource(src), env.WithExplain(&explained))
+	require.ErrorIs(t, err, env.ErrFieldDecode)
+	require.ErrorIs(t, err, env.ErrFieldRequired)
+	require.Len(t, explained, 7)
+
+	require.Equal(t, env.Explanation{
+		Field:  "DB.DSN",
+		Key:    "DB_DSN",
+		Origin: env.Origin{Kind: env.OriginEnv, Key: "DB_DSN"},
+		Shadowed: []env.Origin{
+			{Kind: env.OriginFile, Key: "DB_DSN_FILE", Path: "/run/secrets/dsn"},
+			{Kind: env.OriginEnv, Key: "DB_DSN"},
+		},
+	}, explained[0])
+
+	require.Equal(t, "Port", explained[1].Field)
+	require.Equal(t, env.Origin{Kind: env.OriginEnv, Key: "PORT"}, explained[1].Origin)
+	require.Equal(t, []env.Origin{{Kind: env.OriginDefault}}, explained[1].Shadowed)
+	require.ErrorIs(t, explained[1].Err, env.ErrFieldDecode)
+
+	require.Equal(t, env.Explanation{
+		Field:    "Host",
+		Key:      "HOST",
+		Origin:   env.Origin{Kind: env.OriginEnv, Key: "HOST"},
+		Shadowed: []env.Origin{{Kind: env.OriginDefault}},
+	}, explained[2])
+
+	require.Equal(t, env.Explanation{
+		Field:  "Token",
+		Key:    "TOKEN",
+		Origin: env.Origin{Kind: env.OriginFile, Key: "TOKEN_FILE", Path: "/run/secrets/token"},
+	}, explained[3])
+
+	require.Equal(t, env.Explanation{Field: "Debug", Key: "DEBUG"}, explained[4])
+	require.Equal(t, env.Explanation{Field: "Timeout", Key: "TIMEOUT"}, explained[5])
+
+	require.Equal(t, "Name", explained[6].Field)
+	require.Equal(t, env.OriginUnset, explained[6].Origin.Kind)
+	require.ErrorIs(t, explained[6].Err, env.ErrFieldRequired)
+}
+
+func TestReadStruct_ExplainDefault(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Port int `env:"PORT,default=8080"`
+	}
+
+	var (
+		cfg       config
+		explained []env.Explanation
+	)
+	src := env.MapSource(map[string]string{}, nil)
+	require.NoError(t, env.ReadStruct(&cfg, env.WithSource(src), env.WithExplain(&explained)))
+	require.Equal(t, 8080, cfg.Port)
+	require.Equal(t, []env.Explanation{{
+		Field:  "Port",
+		Key:    "PORT",
+		Origin: env.Origin{Kind: env.OriginDefault},
+	}}, explained)
+}
+
+func TestReadStruct_ExplainFileError(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		P string `env:"P"`
+	}
+
+	var (
+		cfg       config
+		explained []env.Explanation
+	)
+	src := env.MapSource(map[string]string{"P_FILE": "/nope"}, fstest.MapFS{})
+	err := env.ReadStruct(&cfg, env.WithSource(src), env.WithExplain(&explained))
+	require.ErrorIs(t, err, env.ErrFileNotFound)
+
+	require.Len(t, explained, 1)
+	require.Equal(t, env.Origin{Kind: env.OriginFile, Key: "P_FILE", Path: "/nope"}, explained[0].Origin)
+	require.Empty(t, explained[0].Shadowed)
+	require.ErrorIs(t, explained[0].Err, env.ErrFieldFile)
+
+	_, origin, err := env.Lookup("P", env.WithSource(src))
+	require.ErrorIs(t, err, env.ErrFileNotFound)
+	require.Equal(t, "/nope", origin.Path)
+}
@@ -0,0 +1,53 @@
+package env
+
+import "reflect"
+
+// Get reads the variable {key}, `_FILE` variant included, and decodes it into
+// a T with the same decoders as ReadStruct. It fails with ErrFieldRequired
+// when the variable is not set.
+func Get[T any](key string, opts ...Option) (T, error) {
+	var v T
+	_, err := get(&v, tag{key: key, required: true}, newOptions(opts))
+	return v, err
+}
+
+// MustGet is Get panicking on error
+func MustGet[T any](key string, opts ...Option) T {
+	v, err := Get[T](key, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// GetOr is Get returning {def} when the variable is not set. It still fails
+// when the variable is set but cannot be decoded.
+func GetOr[T any](key string, def T, opts ...Option) (T, error) {
+	var v T
+	set, err := get(&v, tag{key: key}, newOptions(opts))
+	if err != nil {
+		return v, err
+	}
+	if !set {
+		return def, nil
+	}
+	return v, nil
+}
+
+// get decodes the variable from {tag} into {dst}, it returns whether the
+// variable is set. Errors are wrapped in a FieldError without field.
+func get(dst any, tag tag, o *options) (bool, error) {
+	rv := reflect.ValueOf(dst).Elem()
+
+	decoded, _, err := decodeField(rv.Type(), tag, "", o)
+	if err != nil {
+		return false, &FieldError{Key: tag.key, Err: err}
+	}
+	if decoded == nil {
+		return false, nil
+	}
+	if err := setField(rv, decoded); err != nil {
+		return false, &FieldError{Key: tag.key, Err: err}
+	}
+	return true, nil
+}
@@ -0,0 +1,77 @@
+package env_test
+
+import (
+	"log/slog"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	src := env.WithSource(env.MapSource(map[string]string{
+		"TIMEOUT":       "30s",
+		"PORT":          "8080",
+		"INVALID":       "abc",
+		"LEVEL":         "debug",
+		"PASSWORD_FILE": "/run/secrets/password",
+	}, fstest.MapFS{"run/secrets/password": {Data: []byte("secret")}}))
+
+	timeout, err := env.Get[time.Duration]("TIMEOUT", src)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, timeout)
+
+	level, err := env.Get[slog.Level]("LEVEL", src)
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelDebug, level)
+
+	password, err := env.Get[string]("PASSWORD", src)
+	require.NoError(t, err)
+	require.Equal(t, "secret", password)
+
+	port, err := env.Get[*int]("PORT", src)
+	require.NoError(t, err)
+	require.NotNil(t, port)
+	require.Equal(t, 8080, *port)
+
+	_, err = env.Get[int]("MISSING", src)
+	require.ErrorIs(t, err, env.ErrFieldRequired)
+	var fieldErr *env.FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	require.Equal(t, "MISSING", fieldErr.Key)
+
+	_, err = env.Get[int]("INVALID", src)
+	require.ErrorIs(t, err, env.ErrFieldDecode)
+
+	_, err = env.Get[chan int]("PORT", src)
+	require.ErrorIs(t, err, env.ErrFieldUnsupported)
+}
+
+func TestMustGet(t *testing.T) {
+	t.Parallel()
+
+	src := env.WithSource(env.MapSource(map[string]string{"PORT": "8080"}, nil))
+	require.Equal(t, 8080, env.MustGet[int]("PORT", src))
+	require.Panics(t, func() { env.MustGet[int]("MISSING", src) })
+}
+
+func TestGetOr(t *testing.T) {
+	t.Parallel()
+
+	src := env.WithSource(env.MapSource(map[string]string{"PORT": "80", "INVALID": "abc"}, nil))
+
+	port, err := env.GetOr("PORT", 8080, src)
+	require.NoError(t, err)
+	require.Equal(t, 80, port)
+
+	port, err = env.GetOr("MISSING", 8080, src)
+	require.NoError(t, err)
+	require.Equal(t, 8080, port)
+
+	_, err = env.GetOr("INVALID", 8080, src)
+	require.ErrorIs(t, err, env.ErrFieldDecode)
+}
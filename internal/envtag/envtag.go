@@ -0,0 +1,27 @@
+// Package envtag holds the grammar of `env` struct tags shared by the env
+// package and the envgen generator.
+package envtag
+
+import "strings"
+
+// Split splits a tag on commas not preceded by a backslash. Escaped commas
+// are unescaped, any other backslash is kept as is.
+func Split(raw string) []string {
+	var (
+		parts []string
+		b     strings.Builder
+	)
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && i+1 < len(raw) && raw[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case raw[i] == ',':
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(raw[i])
+		}
+	}
+	return append(parts, b.String())
+}
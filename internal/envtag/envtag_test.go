@@ -0,0 +1,32 @@
+package envtag_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env/internal/envtag"
+)
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		raw      string
+		expected []string
+	}{
+		{raw: "", expected: []string{""}},
+		{raw: "KEY", expected: []string{"KEY"}},
+		{raw: "KEY,required,default=a", expected: []string{"KEY", "required", "default=a"}},
+		{raw: `KEY,default=a\,b`, expected: []string{"KEY", "default=a,b"}},
+		{raw: `KEY,regex=^\d+$`, expected: []string{"KEY", `regex=^\d+$`}},
+		{raw: `KEY,default=a\`, expected: []string{"KEY", `default=a\`}},
+		{raw: "KEY,,", expected: []string{"KEY", "", ""}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.raw, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, envtag.Split(tc.raw))
+		})
+	}
+}
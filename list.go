@@ -0,0 +1,97 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitList splits {raw} into the items of a list separated by {sep}, like
+// ReadStruct does for slices, arrays and maps :
+//   - blank input is an empty list
+//   - items are trimmed of surrounding whitespace
+//   - a backslash outside quotes escapes the next character, e.g. `\,`
+//   - an item can be quoted with double or single quotes to keep separators
+//     and surrounding whitespace ; `\"` and `\\` are escaped within double
+//     quotes, single quotes are literal
+func SplitList(raw, sep string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return []string{}, nil
+	}
+
+	var (
+		items []string
+		item  strings.Builder
+		// quoted is set once the current item has been quoted, only whitespace
+		// may follow the closing quote
+		quoted bool
+		// pending holds the unquoted whitespace that is kept only when followed
+		// by another character of the item
+		pending strings.Builder
+	)
+	flush := func() {
+		items = append(items, item.String())
+		item.Reset()
+		pending.Reset()
+		quoted = false
+	}
+
+	for i := 0; i < len(raw); {
+		switch c := raw[i]; {
+		case strings.HasPrefix(raw[i:], sep):
+			flush()
+			i += len(sep)
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if item.Len() > 0 && !quoted {
+				pending.WriteByte(c)
+			}
+			i++
+
+		case quoted:
+			return nil, fmt.Errorf("unexpected %q after quoted item", c)
+
+		case c == '"' || c == '\'':
+			if item.Len() > 0 {
+				return nil, fmt.Errorf("unexpected quote in item %q", item.String())
+			}
+			end, err := readQuoted(raw[i:], &item)
+			if err != nil {
+				return nil, err
+			}
+			quoted = true
+			i += end
+
+		case c == '\\' && i+1 < len(raw):
+			item.WriteString(pending.String())
+			pending.Reset()
+			item.WriteByte(raw[i+1])
+			i += 2
+
+		default:
+			item.WriteString(pending.String())
+			pending.Reset()
+			item.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return items, nil
+}
+
+// readQuoted reads the quoted string at the start of {raw} into {b} and
+// returns the index following the closing quote
+func readQuoted(raw string, b *strings.Builder) (int, error) {
+	quote := raw[0]
+	for i := 1; i < len(raw); i++ {
+		switch {
+		case raw[i] == quote:
+			return i + 1, nil
+		case quote == '"' && raw[i] == '\\' && i+1 < len(raw) && (raw[i+1] == '"' || raw[i+1] == '\\'):
+			b.WriteByte(raw[i+1])
+			i++
+		default:
+			b.WriteByte(raw[i])
+		}
+	}
+	return 0, fmt.Errorf("unterminated quote in %q", raw)
+}
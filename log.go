@@ -0,0 +1,91 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// LogValue returns a slog.LogValuer of the struct {v}, or the struct it points
+// to, for loggers to print its effective configuration. It is a group of one
+// attribute per variable, named after its key, holding :
+//   - "value": the value encoded like Marshal, or a mask for secrets ; it is
+//     omitted for nil pointers
+//   - "source": where the value comes from, "env", "file", "default" or
+//     "unset"
+//   - "path": the `_FILE` path, for values read from a file
+//
+// The source is resolved again from the options when the value is logged,
+// without reading files.
+func LogValue(v any, opts ...Option) slog.LogValuer {
+	return configValuer{v: v, opts: newOptions(opts)}
+}
+
+// LogConfig logs the effective configuration of the struct {v}, or the struct
+// it points to, with {logger} at the info level ; slog.Default() when nil.
+// Attributes are the ones of LogValue.
+func LogConfig(logger *slog.Logger, v any, opts ...Option) error {
+	attrs, err := newOptions(opts).configAttrs(v)
+	if err != nil {
+		return err
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.LogAttrs(context.Background(), slog.LevelInfo, "config", attrs...)
+	return nil
+}
+
+type configValuer struct {
+	v    any
+	opts *options
+}
+
+func (c configValuer) LogValue() slog.Value {
+	attrs, err := c.opts.configAttrs(c.v)
+	if err != nil {
+		return slog.GroupValue(slog.String("error", err.Error()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// configAttrs returns the attributes of the variables bound to {v}
+func (o *options) configAttrs(v any) ([]slog.Attr, error) {
+	rv, fields, err := describeValue(v, o)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		origin := o.locateField(f.keys(), f.FileKey != "")
+		if origin.Kind == OriginUnset && f.HasDefault {
+			origin.Kind = OriginDefault
+		}
+
+		group := make([]slog.Attr, 0, 3)
+		if fieldValue, ok := f.valueOf(rv); ok {
+			group = append(group, slog.String("value", o.logValue(f, fieldValue.Interface())))
+		}
+		group = append(group, slog.String("source", origin.Kind.String()))
+		if origin.Kind == OriginFile {
+			group = append(group, slog.String("path", origin.Path))
+		}
+		attrs = append(attrs, slog.Attr{Key: f.Key, Value: slog.GroupValue(group...)})
+	}
+	return attrs, nil
+}
+
+// logValue formats the value {v} of the field {f}: masked for secrets, encoded
+// like Marshal otherwise
+func (o *options) logValue(f describedField, v any) string {
+	if f.Secret {
+		return secretMask
+	}
+	if encoder, ok := o.fieldEncoder(f.typ, f.tag); ok {
+		if raw, err := encoder(v); err == nil {
+			return raw
+		}
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,80 @@
+package env_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+type logConfig struct {
+	Host     string             `env:"HOST"`
+	Password string             `env:"PASSWORD,secret"`
+	Port     int                `env:"PORT,default=8080"`
+	Timeout  *time.Duration     `env:"TIMEOUT"`
+	Token    env.Secret[string] `env:"TOKEN"`
+	Tags     []string           `env:"TAGS"`
+}
+
+func TestLogConfig(t *testing.T) {
+	t.Parallel()
+
+	src := env.WithSource(env.MapSource(map[string]string{
+		"HOST":          "localhost",
+		"PASSWORD_FILE": "/run/secrets/password",
+		"TOKEN":         "token",
+		"TAGS":          "a,b",
+	}, nil))
+	cfg := logConfig{
+		Host:     "localhost",
+		Password: "p@ss",
+		Port:     8080,
+		Token:    env.NewSecret("token"),
+		Tags:     []string{"a", "b"},
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	require.NoError(t, env.LogConfig(logger, &cfg, src))
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	require.Equal(t, map[string]any{
+		"level":    "INFO",
+		"msg":      "config",
+		"HOST":     map[string]any{"value": "localhost", "source": "env"},
+		"PASSWORD": map[string]any{"value": "***", "source": "file", "path": "/run/secrets/password"},
+		"PORT":     map[string]any{"value": "8080", "source": "default"},
+		"TIMEOUT":  map[string]any{"source": "unset"},
+		"TOKEN":    map[string]any{"value": "***", "source": "env"},
+		"TAGS":     map[string]any{"value": "a,b", "source": "env"},
+	}, got)
+	require.NotContains(t, buf.String(), "p@ss")
+	require.NotContains(t, buf.String(), `"token"`)
+
+	require.ErrorIs(t, env.LogConfig(logger, nil), env.ErrNotPtr)
+}
+
+func TestLogValue(t *testing.T) {
+	t.Parallel()
+
+	src := env.WithSource(env.MapSource(map[string]string{"HOST": "localhost"}, nil))
+	cfg := logConfig{Host: "localhost", Password: "p@ss"}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("starting", "config", env.LogValue(cfg, src))
+	require.Contains(t, buf.String(), "config.HOST.value=localhost config.HOST.source=env")
+	require.Contains(t, buf.String(), "config.PASSWORD.value=*** config.PASSWORD.source=unset")
+	require.NotContains(t, buf.String(), "p@ss")
+}
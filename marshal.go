@@ -0,0 +1,138 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Marshal encodes the struct {v}, or the struct it points to, into "KEY=value"
+// entries in field order: the inverse of ReadStruct. Values are encoded with
+// the encoders matching the decoders, lists and maps are joined with the
+// separators of their tag, and the `$` of `expand` fields are escaped as `$$`.
+// Nil pointers are left unset. Failing fields are reported as Errors.
+func Marshal(v any, opts ...Option) ([]string, error) {
+	vars, err := newOptions(opts).marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	environ := make([]string, len(vars))
+	for i, v := range vars {
+		environ[i] = v.key + "=" + v.value
+	}
+	return environ, nil
+}
+
+// MarshalMap is Marshal returning the variables by key
+func MarshalMap(v any, opts ...Option) (map[string]string, error) {
+	vars, err := newOptions(opts).marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(vars))
+	for _, v := range vars {
+		m[v.key] = v.value
+	}
+	return m, nil
+}
+
+// SetCmdEnv appends the variables encoded from {v} to the environment of
+// {cmd}, the environment of the current process when cmd.Env is nil. Encoded
+// variables take precedence over inherited ones.
+func SetCmdEnv(cmd *exec.Cmd, v any, opts ...Option) error {
+	environ, err := Marshal(v, opts...)
+	if err != nil {
+		return err
+	}
+	cmd.Env = append(cmd.Environ(), environ...)
+	return nil
+}
+
+// encodedVar is a variable encoded by Marshal
+type encodedVar struct {
+	key, value string
+}
+
+func (o *options) marshal(v any) ([]encodedVar, error) {
+	rv, fields, err := describeValue(v, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		vars    []encodedVar
+		secrets []secretVar
+		errs    Errors
+	)
+	for _, f := range fields {
+		fieldValue, ok := f.valueOf(rv)
+		if !ok {
+			continue
+		}
+
+		encoder, ok := o.fieldEncoder(f.typ, f.tag)
+		if !ok {
+			err := fmt.Errorf("%w: %q", ErrFieldUnsupported, f.typ.String())
+			errs = append(errs, &FieldError{Field: f.Field, Key: f.Key, Err: err})
+			continue
+		}
+		raw, err := encoder(fieldValue.Interface())
+		if err != nil {
+			errs = append(errs, &FieldError{Field: f.Field, Key: f.Key, Err: fmt.Errorf("%w: %w", ErrFieldEncode, err)})
+			continue
+		}
+		// ReadStruct expands the value again
+		if f.tag.expand {
+			raw = strings.ReplaceAll(raw, "$", "$$")
+		}
+
+		if !f.Secret || f.FileKey == "" || o.secretDir == "" {
+			vars = append(vars, encodedVar{key: f.Key, value: raw})
+			continue
+		}
+		secrets = append(secrets, secretVar{field: f, index: len(vars)})
+		vars = append(vars, encodedVar{key: f.FileKey, value: raw})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// secret files are only written once every field is encoded, and removed
+	// when one of them cannot be written
+	var written []string
+	for _, s := range secrets {
+		path, err := writeSecret(o.secretDir, vars[s.index].value)
+		if err != nil {
+			for _, path := range written {
+				os.Remove(path)
+			}
+			return nil, Errors{{Field: s.field.Field, Key: s.field.Key, Err: fmt.Errorf("%w: %w", ErrFieldEncode, err)}}
+		}
+		written = append(written, path)
+		vars[s.index].value = path
+	}
+	return vars, nil
+}
+
+// secretVar is a secret field which value Marshal writes to a file, {index}
+// is its variable in the encoded ones
+type secretVar struct {
+	field describedField
+	index int
+}
+
+// writeSecret writes {value} to a new file of {dir} readable by its owner only
+// and returns its path
+func writeSecret(dir, value string) (string, error) {
+	f, err := os.CreateTemp(dir, "secret-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(value); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
@@ -0,0 +1,192 @@
+package env_test
+
+import (
+	"log/slog"
+	"net/netip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+type marshalDB struct {
+	Host     string `env:"HOST,required"`
+	Password string `env:"PASSWORD,secret"`
+}
+
+type marshalConfig struct {
+	DB       marshalDB             `envPrefix:"DB_"`
+	Replica  *marshalDB            `envPrefix:"REPLICA_"`
+	Port     int                   `env:"PORT"`
+	Ratio    float32               `env:"RATIO"`
+	Debug    bool                  `env:"DEBUG"`
+	Timeout  time.Duration         `env:"TIMEOUT"`
+	Since    time.Time             `env:"SINCE"`
+	Level    slog.Level            `env:"LOG_LEVEL"`
+	Addr     netip.Addr            `env:"ADDR"`
+	Token    []byte                `env:"TOKEN,secret,nofile"`
+	Workers  *int                  `env:"WORKERS"`
+	Brokers  []string              `env:"BROKERS"`
+	Ports    [2]uint16             `env:"PORTS,sep=;"`
+	Limits   map[string]int        `env:"LIMITS,kvsep=="`
+	Features map[string]struct{}   `env:"FEATURES"`
+	Delays   map[int]time.Duration `env:"DELAYS"`
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+
+	cfg := marshalConfig{
+		DB:       marshalDB{Host: "localhost", Password: "p@ss"},
+		Replica:  &marshalDB{Host: "replica"},
+		Port:     8080,
+		Ratio:    0.1,
+		Debug:    true,
+		Timeout:  90 * time.Second,
+		Since:    time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		Level:    slog.LevelWarn,
+		Addr:     netip.MustParseAddr("10.0.0.1"),
+		Token:    []byte("token"),
+		Brokers:  []string{"kafka-1:9092", "a,b", ` c `, `"q"`, ""},
+		Ports:    [2]uint16{80, 443},
+		Limits:   map[string]int{"b": 2, "a": 1},
+		Features: map[string]struct{}{"x": {}, "y": {}},
+		Delays:   map[int]time.Duration{1: time.Second},
+	}
+
+	environ, err := env.Marshal(cfg)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"DB_HOST=localhost",
+		"DB_PASSWORD=p@ss",
+		"REPLICA_HOST=replica",
+		"REPLICA_PASSWORD=",
+		"PORT=8080",
+		"RATIO=0.1",
+		"DEBUG=true",
+		"TIMEOUT=1m30s",
+		"SINCE=2024-01-02T03:04:05.000000006Z",
+		"LOG_LEVEL=warn",
+		"ADDR=10.0.0.1",
+		"TOKEN=token",
+		`BROKERS=kafka-1:9092,"a,b"," c ","\"q\"",`,
+		"PORTS=80;443",
+		"LIMITS=a=1,b=2",
+		"FEATURES=x,y",
+		"DELAYS=1:1s",
+	}, environ)
+
+	// round trip
+	vars, err := env.MarshalMap(&cfg)
+	require.NoError(t, err)
+	var got marshalConfig
+	require.NoError(t, env.ReadStruct(&got, env.WithSource(env.MapSource(vars, nil))))
+	require.Equal(t, cfg, got)
+}
+
+func TestMarshal_SecretFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := marshalConfig{
+		DB:      marshalDB{Host: "localhost", Password: "p@ss"},
+		Replica: &marshalDB{Host: "replica"},
+		Token:   []byte("token"),
+	}
+	vars, err := env.MarshalMap(cfg, env.WithSecretFiles(dir))
+	require.NoError(t, err)
+
+	// secret fields accepting a `_FILE` variant are written to files
+	require.NotContains(t, vars, "DB_PASSWORD")
+	require.Equal(t, dir, filepath.Dir(vars["DB_PASSWORD_FILE"]))
+	content, err := os.ReadFile(vars["DB_PASSWORD_FILE"])
+	require.NoError(t, err)
+	require.Equal(t, "p@ss", string(content))
+	require.Contains(t, vars, "REPLICA_PASSWORD_FILE")
+	require.Equal(t, "token", vars["TOKEN"])
+
+	var got marshalConfig
+	require.NoError(t, env.ReadStruct(&got, env.WithSource(env.MapSource(vars, os.DirFS("/")))))
+	require.Equal(t, "p@ss", got.DB.Password)
+}
+
+func TestMarshal_Expand(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		DSN      string `env:"DSN,expand"`
+		Password string `env:"PASSWORD,secret,expand"`
+		Raw      string `env:"RAW"`
+	}
+	cfg := config{DSN: "pa$$word${X}", Password: "$ecret", Raw: "$X"}
+
+	environ, err := env.Marshal(cfg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"DSN=pa$$$$word$${X}", "PASSWORD=$$ecret", "RAW=$X"}, environ)
+
+	// round trip, through a secret file too
+	vars, err := env.MarshalMap(cfg, env.WithSecretFiles(t.TempDir()))
+	require.NoError(t, err)
+	vars["X"] = "expanded"
+	var got config
+	require.NoError(t, env.ReadStruct(&got, env.WithSource(env.MapSource(vars, os.DirFS("/")))))
+	require.Equal(t, cfg, got)
+}
+
+func TestMarshal_SecretFilesErrors(t *testing.T) {
+	t.Parallel()
+
+	// no file is left behind when a later field fails to encode
+	dir := t.TempDir()
+	_, err := env.Marshal(struct {
+		Password string     `env:"PASSWORD,secret"`
+		Level    slog.Level `env:"LEVEL"`
+	}{Password: "p@ss", Level: slog.LevelDebug + 2}, env.WithSecretFiles(dir))
+	require.ErrorIs(t, err, env.ErrFieldEncode)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	_, err = env.Marshal(struct {
+		Password string `env:"PASSWORD,secret"`
+	}{Password: "p@ss"}, env.WithSecretFiles(filepath.Join(dir, "missing")))
+	require.ErrorIs(t, err, env.ErrFieldEncode)
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := env.Marshal((*marshalConfig)(nil))
+	require.ErrorIs(t, err, env.ErrNotPtr)
+
+	_, err = env.Marshal(struct {
+		Level slog.Level        `env:"LEVEL"`
+		Map   map[string]string `env:"MAP"`
+	}{Level: slog.LevelDebug + 2, Map: map[string]string{"a:b": "c"}})
+	require.ErrorIs(t, err, env.ErrFieldEncode)
+
+	var errs env.Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 2)
+	require.Equal(t, "LEVEL", errs[0].Key)
+	require.Equal(t, "MAP", errs[1].Key)
+}
+
+func TestSetCmdEnv(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("worker")
+	cmd.Env = []string{"HOME=/root", "PORT=1"}
+	cfg := &marshalConfig{Port: 8080}
+	require.NoError(t, env.SetCmdEnv(cmd, cfg))
+
+	environ, err := env.Marshal(cfg)
+	require.NoError(t, err)
+	require.Contains(t, environ, "PORT=8080")
+	require.Equal(t, append([]string{"HOME=/root", "PORT=1"}, environ...), cmd.Env)
+}
@@ -0,0 +1,115 @@
+package env
+
+import (
+	"io/fs"
+	"reflect"
+)
+
+// Option configures a single call to Read or ReadStruct
+type Option func(*options)
+
+type options struct {
+	// source provides the variables, the process environment by default
+	source Source
+	// files defines how `_FILE` variables are resolved
+	files FilePolicy
+	// decoders override the registry for a single call
+	decoders map[reflect.Type]DecoderFn
+	// encoders override the registry for a single call
+	encoders map[reflect.Type]EncoderFn
+	// validators override the registry for a single call
+	validators map[string]ValidatorFn
+	// secretDir is the directory Marshal writes secret fields to, as `_FILE`
+	// variables, when set
+	secretDir string
+	// prefix is prepended to the keys of struct fields
+	prefix string
+	// onDeprecated is called when a deprecated alias is used, the default
+	// logs a warning
+	onDeprecated func(old, key string)
+	// explain receives the explanations of ReadStruct, when set
+	explain *[]Explanation
+	// onFile is called with every `_FILE` path before it is read
+	onFile func(fsys fs.FS, path string)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{source: OSSource()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithDecoder uses {fn} to decode values of the type {t} for a single call. It
+// takes precedence over decoders registered with RegisterDecoder.
+func WithDecoder(t reflect.Type, fn DecoderFn) Option {
+	return func(o *options) {
+		if o.decoders == nil {
+			o.decoders = make(map[reflect.Type]DecoderFn)
+		}
+		o.decoders[t] = fn
+	}
+}
+
+// WithEncoder uses {fn} to encode values of the type {t} for a single call. It
+// takes precedence over encoders registered with RegisterEncoder.
+func WithEncoder(t reflect.Type, fn EncoderFn) Option {
+	return func(o *options) {
+		if o.encoders == nil {
+			o.encoders = make(map[reflect.Type]EncoderFn)
+		}
+		o.encoders[t] = fn
+	}
+}
+
+// WithSource reads the variables and the `_FILE` files from {src} instead of
+// the process environment
+func WithSource(src Source) Option {
+	return func(o *options) { o.source = src }
+}
+
+// WithFilePolicy resolves the `_FILE` variables according to {p}
+func WithFilePolicy(p FilePolicy) Option {
+	return func(o *options) { o.files = p }
+}
+
+// WithValidator uses {fn} as the validator {name} for a single call. It takes
+// precedence over validators registered with RegisterValidator.
+func WithValidator(name string, fn ValidatorFn) Option {
+	return func(o *options) {
+		if o.validators == nil {
+			o.validators = make(map[string]ValidatorFn)
+		}
+		o.validators[name] = fn
+	}
+}
+
+// WithSecretFiles makes Marshal write the value of every secret field to a
+// new file of {dir} and encode the `_FILE` variant of its key with the path
+// instead of the value. The caller removes the files once they are read ; no
+// file is left when Marshal fails.
+func WithSecretFiles(dir string) Option {
+	return func(o *options) { o.secretDir = dir }
+}
+
+// WithExplain makes ReadStruct store in {dst} where the value of every field
+// comes from, see Explanation
+func WithExplain(dst *[]Explanation) Option {
+	return func(o *options) { o.explain = dst }
+}
+
+// WithPrefix prepends {prefix} to the key of every struct field, before the
+// `envPrefix` of nested structs, so that a struct type can be read from
+// several namespaces. It applies to ReadStruct, Describe, Usage, Schema,
+// Marshal and LogValue ; Read and the references of Expand are not prefixed.
+func WithPrefix(prefix string) Option {
+	return func(o *options) { o.prefix = prefix }
+}
+
+// WithDeprecationHook calls {fn} with the alias and the key of a field when
+// ReadStruct reads it from a `deprecated` alias. By default, a warning is
+// logged with slog.Default.
+func WithDeprecationHook(fn func(old, key string)) Option {
+	return func(o *options) { o.onDeprecated = fn }
+}
@@ -0,0 +1,67 @@
+package env_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+type prefixConfig struct {
+	Name string `env:"NAME,required"`
+	DB   struct {
+		Host     string `env:"HOST,default=localhost"`
+		Password string `env:"PASSWORD,secret"`
+	} `envPrefix:"DB_"`
+}
+
+func TestReadStructPrefix(t *testing.T) {
+	t.Parallel()
+
+	src := env.MapSource(map[string]string{
+		"APP_NAME":                "app",
+		"APP_DB_HOST":             "app.db",
+		"WORKER_NAME":             "worker",
+		"WORKER_DB_PASSWORD_FILE": "/run/secrets/password",
+		"NAME":                    "unprefixed",
+	}, fstest.MapFS{"run/secrets/password": {Data: []byte("p@ss")}})
+
+	var app prefixConfig
+	require.NoError(t, env.ReadStructPrefix("APP_", &app, env.WithSource(src)))
+	require.Equal(t, "app", app.Name)
+	require.Equal(t, "app.db", app.DB.Host)
+	require.Empty(t, app.DB.Password)
+
+	var worker prefixConfig
+	require.NoError(t, env.ReadStruct(&worker, env.WithSource(src), env.WithPrefix("WORKER_")))
+	require.Equal(t, "worker", worker.Name)
+	require.Equal(t, "localhost", worker.DB.Host)
+	require.Equal(t, "p@ss", worker.DB.Password)
+
+	var missing prefixConfig
+	err := env.ReadStructPrefix("CRON_", &missing, env.WithSource(src))
+	require.ErrorIs(t, err, env.ErrFieldRequired)
+	require.EqualError(t, err, `field "Name" (CRON_NAME): field is required`)
+}
+
+func TestDescribe_Prefix(t *testing.T) {
+	t.Parallel()
+
+	vars, err := env.Describe(&prefixConfig{}, env.WithPrefix("APP_"))
+	require.NoError(t, err)
+	require.Len(t, vars, 3)
+	require.Equal(t, "APP_NAME", vars[0].Key)
+	require.Equal(t, "APP_NAME_FILE", vars[0].FileKey)
+	require.Equal(t, "APP_DB_HOST", vars[1].Key)
+	require.Equal(t, "APP_DB_PASSWORD", vars[2].Key)
+
+	environ, err := env.Marshal(&prefixConfig{Name: "app"}, env.WithPrefix("APP_"))
+	require.NoError(t, err)
+	require.Contains(t, environ, "APP_NAME=app")
+
+	schema, err := env.Schema(&prefixConfig{}, env.WithPrefix("APP_"))
+	require.NoError(t, err)
+	require.Contains(t, string(schema), `"APP_DB_HOST"`)
+	require.NotContains(t, string(schema), `"DB_HOST"`)
+}
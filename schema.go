@@ -0,0 +1,162 @@
+package env
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// SchemaDraft is the JSON Schema dialect produced by Schema
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema returns a JSON Schema (draft 2020-12) document describing the
+// environment expected by the struct pointed to by {v}, from the same tags as
+// ReadStruct. The environment is an object of string properties, one per
+// variable, carrying its description, default, `oneof` enumeration, `regex`
+// pattern and `minlen` and `maxlen` lengths. The `min` and `max` ranges only
+// apply to decoded values, they are extension keywords enforced by envcheck.
+//
+// A required variable accepting a `_FILE` variant or aliases is satisfied by
+// any of them. The following extension keywords are set for tools like envcheck :
+//   - "x-go-type": the Go type of the field
+//   - "x-env-file": the `_FILE` variant of the key, when accepted
+//   - "x-minimum" and "x-maximum": the raw range of numbers and durations
+//   - "x-env-aliases" and "x-env-deprecated": the aliases of the key, read
+//     when it is not set, and the deprecated ones
+//   - "x-env-expand": the value is expanded before decoding, the other
+//     keywords apply to the expanded value
+func Schema(v any, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	fields, err := describe(v, o, true)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := schemaDocument{
+		Schema:               SchemaDraft,
+		Title:                reflect.TypeOf(v).Elem().Name(),
+		Type:                 "object",
+		Properties:           make(map[string]schemaProperty, len(fields)),
+		AdditionalProperties: true,
+	}
+	for _, f := range fields {
+		doc.Properties[f.Key] = newSchemaProperty(f)
+
+		switch {
+		case !f.Required:
+		case f.FileKey == "" && len(f.Aliases) == 0:
+			doc.Required = append(doc.Required, f.Key)
+		default:
+			var group schemaAnyOf
+			for _, key := range f.keys() {
+				group.AnyOf = append(group.AnyOf, schemaRequired{Required: []string{key}})
+				if f.FileKey != "" {
+					fileKey := key + o.files.suffix()
+					group.AnyOf = append(group.AnyOf, schemaRequired{Required: []string{fileKey}})
+				}
+			}
+			doc.AllOf = append(doc.AllOf, group)
+		}
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type schemaDocument struct {
+	Schema               string                    `json:"$schema"`
+	Title                string                    `json:"title,omitempty"`
+	Type                 string                    `json:"type"`
+	Properties           map[string]schemaProperty `json:"properties"`
+	Required             []string                  `json:"required,omitempty"`
+	AllOf                []schemaAnyOf             `json:"allOf,omitempty"`
+	AdditionalProperties bool                      `json:"additionalProperties"`
+}
+
+type schemaAnyOf struct {
+	AnyOf []schemaRequired `json:"anyOf"`
+}
+
+type schemaRequired struct {
+	Required []string `json:"required"`
+}
+
+type schemaProperty struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Default     *string  `json:"default,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	Format      string   `json:"format,omitempty"`
+	MinLength   *int     `json:"minLength,omitempty"`
+	MaxLength   *int     `json:"maxLength,omitempty"`
+	WriteOnly   bool     `json:"writeOnly,omitempty"`
+	GoType      string   `json:"x-go-type"`
+	FileKey     string   `json:"x-env-file,omitempty"`
+	Expand      bool     `json:"x-env-expand,omitempty"`
+	Aliases     []string `json:"x-env-aliases,omitempty"`
+	Deprecated  []string `json:"x-env-deprecated,omitempty"`
+	XMinimum    string   `json:"x-minimum,omitempty"`
+	XMaximum    string   `json:"x-maximum,omitempty"`
+}
+
+// patterns of the raw values accepted by the default decoders
+const (
+	intPattern      = `^[+-]?[0-9]+$`
+	uintPattern     = `^[0-9]+$`
+	durationPattern = `^([+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+$|^0$`
+)
+
+// boolValues are the raw values accepted by strconv.ParseBool
+var boolValues = []string{"1", "t", "T", "TRUE", "true", "True", "0", "f", "F", "FALSE", "false", "False"}
+
+func newSchemaProperty(f describedField) schemaProperty {
+	// secrets are constrained like the value they hold
+	typ := f.typ
+	if valueType, ok := secretValueType(typ); ok {
+		typ = valueType
+	}
+
+	p := schemaProperty{
+		Type:        "string",
+		Description: f.Description,
+		Enum:        f.tag.rules.oneOf,
+		WriteOnly:   f.Secret,
+		GoType:      typ.String(),
+		FileKey:     f.FileKey,
+		Expand:      f.tag.expand,
+		Aliases:     f.Aliases,
+		Deprecated:  f.Deprecated,
+	}
+	if f.HasDefault && !f.Secret {
+		p.Default = &f.Default
+	}
+
+	// type constraints, for the types decoded by the default decoders only
+	switch {
+	case typ == reflect.TypeFor[time.Duration]():
+		p.Pattern = durationPattern
+		p.XMinimum, p.XMaximum = f.tag.rules.min, f.tag.rules.max
+	case typ == reflect.TypeFor[time.Time]():
+		p.Format = "date-time"
+	case typ.PkgPath() != "":
+	default:
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			p.Pattern = intPattern
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			p.Pattern = uintPattern
+		case reflect.Bool:
+			if p.Enum == nil {
+				p.Enum = boolValues
+			}
+		case reflect.String:
+			p.MinLength, p.MaxLength = f.tag.rules.minLen, f.tag.rules.maxLen
+		}
+		p.XMinimum, p.XMaximum = f.tag.rules.min, f.tag.rules.max
+	}
+
+	// user patterns take precedence over type patterns
+	if f.tag.rules.pattern != nil {
+		p.Pattern = f.tag.rules.pattern.String()
+	}
+	return p
+}
@@ -0,0 +1,130 @@
+package env_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+type schemaConfig struct {
+	DB      describeDB    `envPrefix:"DB_"`
+	Port    int           `env:"PORT,default=8080,min=1,max=65535,nofile" desc:"listening port"`
+	Mode    string        `env:"MODE,required,nofile,oneof=dev|prod"`
+	Name    string        `env:"NAME,regex=^[a-z]+$,minlen=2,maxlen=8"`
+	Timeout time.Duration `env:"TIMEOUT,default=5s,min=1s"`
+	Debug   bool          `env:"DEBUG"`
+	Since   time.Time     `env:"SINCE"`
+	Token   string        `env:"TOKEN,secret,default=dev-token"`
+}
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	raw, err := env.Schema(&schemaConfig{})
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(raw, &got))
+
+	expected := map[string]any{
+		"$schema": env.SchemaDraft,
+		"title":   "schemaConfig",
+		"type":    "object",
+		"properties": map[string]any{
+			"DB_HOST": map[string]any{
+				"type":        "string",
+				"description": "database host",
+				"x-go-type":   "string",
+				"x-env-file":  "DB_HOST_FILE",
+			},
+			"DB_PASSWORD": map[string]any{
+				"type":        "string",
+				"description": "database password",
+				"writeOnly":   true,
+				"x-go-type":   "string",
+				"x-env-file":  "DB_PASSWORD_FILE",
+			},
+			"PORT": map[string]any{
+				"type":        "string",
+				"description": "listening port",
+				"default":     "8080",
+				"pattern":     `^[+-]?[0-9]+$`,
+				"x-go-type":   "int",
+				"x-minimum":   "1",
+				"x-maximum":   "65535",
+			},
+			"MODE": map[string]any{
+				"type":      "string",
+				"enum":      []any{"dev", "prod"},
+				"x-go-type": "string",
+			},
+			"NAME": map[string]any{
+				"type":       "string",
+				"pattern":    "^[a-z]+$",
+				"minLength":  2.0,
+				"maxLength":  8.0,
+				"x-go-type":  "string",
+				"x-env-file": "NAME_FILE",
+			},
+			"TIMEOUT": map[string]any{
+				"type":       "string",
+				"default":    "5s",
+				"pattern":    `^([+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+$|^0$`,
+				"x-go-type":  "time.Duration",
+				"x-env-file": "TIMEOUT_FILE",
+				"x-minimum":  "1s",
+			},
+			"DEBUG": map[string]any{
+				"type":       "string",
+				"enum":       []any{"1", "t", "T", "TRUE", "true", "True", "0", "f", "F", "FALSE", "false", "False"},
+				"x-go-type":  "bool",
+				"x-env-file": "DEBUG_FILE",
+			},
+			"SINCE": map[string]any{
+				"type":       "string",
+				"format":     "date-time",
+				"x-go-type":  "time.Time",
+				"x-env-file": "SINCE_FILE",
+			},
+			"TOKEN": map[string]any{
+				"type":       "string",
+				"writeOnly":  true,
+				"x-go-type":  "string",
+				"x-env-file": "TOKEN_FILE",
+			},
+		},
+		"required": []any{"MODE"},
+		"allOf": []any{
+			map[string]any{"anyOf": []any{
+				map[string]any{"required": []any{"DB_HOST"}},
+				map[string]any{"required": []any{"DB_HOST_FILE"}},
+			}},
+			map[string]any{"anyOf": []any{
+				map[string]any{"required": []any{"DB_PASSWORD"}},
+				map[string]any{"required": []any{"DB_PASSWORD_FILE"}},
+			}},
+		},
+		"additionalProperties": true,
+	}
+	require.Equal(t, expected, got)
+}
+
+func TestSchema_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := env.Schema(describeConfig{})
+	require.ErrorIs(t, err, env.ErrNotPtr)
+
+	_, err = env.Schema(&struct {
+		C chan int `env:"C"`
+	}{})
+	require.ErrorIs(t, err, env.ErrFieldUnsupported)
+
+	_, err = env.Schema(&struct {
+		Name string `env:"NAME,omitempty"`
+	}{})
+	require.ErrorIs(t, err, env.ErrFieldTag)
+}
@@ -0,0 +1,105 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+)
+
+// secretMask replaces secret values wherever they would be displayed
+const secretMask = "***"
+
+// Secret holds a value that is never displayed: fmt verbs, JSON and slog all
+// print a mask instead. ReadStruct decodes a Secret[T] field like a T field,
+// tag options included, and marks it as secret like the `secret` option.
+type Secret[T any] struct {
+	value T
+}
+
+// NewSecret returns a Secret holding {v}
+func NewSecret[T any](v T) Secret[T] { return Secret[T]{value: v} }
+
+// Value returns the secret value
+func (s Secret[T]) Value() T { return s.value }
+
+// String returns a mask
+func (s Secret[T]) String() string { return secretMask }
+
+// GoString returns a mask, along with the type for %#v
+func (s Secret[T]) GoString() string {
+	return fmt.Sprintf("env.Secret[%s]{%s}", reflect.TypeFor[T](), secretMask)
+}
+
+// Format writes a mask whatever the verb
+func (s Secret[T]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, s.GoString())
+		return
+	}
+	io.WriteString(f, secretMask)
+}
+
+// MarshalJSON returns a mask as a JSON string
+func (s Secret[T]) MarshalJSON() ([]byte, error) { return json.Marshal(secretMask) }
+
+// LogValue returns a mask for slog
+func (s Secret[T]) LogValue() slog.Value { return slog.StringValue(secretMask) }
+
+// Clear zeroes the value. The contents of slices, e.g. []byte, are zeroed
+// too, strings are immutable and can only be dropped.
+func (s *Secret[T]) Clear() {
+	v := reflect.ValueOf(&s.value).Elem()
+	if v.Kind() == reflect.Slice {
+		v.Clear()
+	}
+	v.SetZero()
+}
+
+// secret is implemented by Secret types, for ReadStruct and Marshal to handle
+// them like the value they hold
+type secret interface {
+	secretType() reflect.Type
+	secretValue() any
+}
+
+func (Secret[T]) secretType() reflect.Type { return reflect.TypeFor[T]() }
+func (s Secret[T]) secretValue() any       { return s.value }
+
+func (s *Secret[T]) setSecretValue(v any) error {
+	value, ok := v.(T)
+	if !ok {
+		return fmt.Errorf("cannot assign %T to %s", v, reflect.TypeFor[T]())
+	}
+	s.value = value
+	return nil
+}
+
+var secretInterface = reflect.TypeFor[secret]()
+
+// secretValueType returns the type of the value held by the Secret type {t},
+// and whether {t} is a Secret type
+func secretValueType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || !t.Implements(secretInterface) {
+		return nil, false
+	}
+	return reflect.Zero(t).Interface().(secret).secretType(), true
+}
+
+// newSecret returns a value of the Secret type {t} holding {v}
+func newSecret(t reflect.Type, v any) (any, error) {
+	ptr := reflect.New(t)
+	if err := ptr.Interface().(interface{ setSecretValue(any) error }).setSecretValue(v); err != nil {
+		return nil, err
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+// unwrapSecret returns the value held by {v} when it is a Secret
+func unwrapSecret(v any) any {
+	if s, ok := v.(secret); ok {
+		return s.secretValue()
+	}
+	return v
+}
@@ -0,0 +1,99 @@
+package env_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+type secretConfig struct {
+	Host     string               `env:"HOST"`
+	Password env.Secret[string]   `env:"PASSWORD,required"`
+	Key      env.Secret[[]byte]   `env:"KEY"`
+	Port     *env.Secret[int]     `env:"PORT,min=1"`
+	Tokens   env.Secret[[]string] `env:"TOKENS,sep=;"`
+}
+
+func TestSecret(t *testing.T) {
+	t.Parallel()
+
+	s := env.NewSecret("p@ss")
+	require.Equal(t, "p@ss", s.Value())
+
+	cfg := struct {
+		Password env.Secret[string]
+	}{Password: s}
+	for _, format := range []string{"%v", "%+v", "%s", "%q", "%x", "%d"} {
+		require.NotContains(t, fmt.Sprintf(format, cfg), "p@ss", format)
+		require.NotContains(t, fmt.Sprintf(format, s), "p@ss", format)
+	}
+	require.Equal(t, "{Password:***}", fmt.Sprintf("%+v", cfg))
+	require.Equal(t, "env.Secret[string]{***}", fmt.Sprintf("%#v", s))
+	require.Equal(t, "***", s.String())
+
+	raw, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Password":"***"}`, string(raw))
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("config", "password", s)
+	require.Contains(t, buf.String(), "password=***")
+	require.NotContains(t, buf.String(), "p@ss")
+}
+
+func TestSecret_Clear(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("key")
+	s := env.NewSecret(key)
+	s.Clear()
+	require.Nil(t, s.Value())
+	require.Equal(t, []byte{0, 0, 0}, key)
+}
+
+func TestReadStruct_Secret(t *testing.T) {
+	t.Parallel()
+
+	src := env.MapSource(map[string]string{
+		"HOST":          "localhost",
+		"PASSWORD_FILE": "/run/secrets/password",
+		"KEY":           "key",
+		"PORT":          "8080",
+		"TOKENS":        "a;b",
+	}, fstest.MapFS{"run/secrets/password": {Data: []byte("p@ss")}})
+
+	var cfg secretConfig
+	require.NoError(t, env.ReadStruct(&cfg, env.WithSource(src)))
+	require.Equal(t, "p@ss", cfg.Password.Value())
+	require.Equal(t, []byte("key"), cfg.Key.Value())
+	require.Equal(t, 8080, cfg.Port.Value())
+	require.Equal(t, []string{"a", "b"}, cfg.Tokens.Value())
+
+	// validation applies to the value
+	src = env.MapSource(map[string]string{"PASSWORD": "p@ss", "PORT": "0"}, nil)
+	err := env.ReadStruct(&secretConfig{}, env.WithSource(src))
+	require.ErrorIs(t, err, env.ErrFieldRange)
+
+	// typed accessors
+	port, err := env.Get[env.Secret[int]]("PORT", env.WithSource(env.MapSource(map[string]string{"PORT": "8080"}, nil)))
+	require.NoError(t, err)
+	require.Equal(t, 8080, port.Value())
+
+	// Secret fields are secret
+	vars, err := env.Describe(&secretConfig{})
+	require.NoError(t, err)
+	require.False(t, vars[0].Secret)
+	require.True(t, vars[1].Secret)
+	require.True(t, vars[3].Secret)
+
+	// Marshal encodes the value
+	environ, err := env.Marshal(cfg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"HOST=localhost", "PASSWORD=p@ss", "KEY=key", "PORT=8080", "TOKENS=a;b"}, environ)
+}
@@ -0,0 +1,144 @@
+package env
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// Source provides the environment variables and the files referenced by the
+// `_FILE` variables
+type Source interface {
+	// Lookup returns the value of the variable {key} and whether it is set
+	Lookup(key string) (string, bool)
+	// FS is the file system `_FILE` paths are read from
+	FS() fs.FS
+}
+
+// OSSource returns the process environment. `_FILE` paths are read from the
+// host file system, either absolute or relative to the working directory.
+func OSSource() Source { return osSource{} }
+
+type osSource struct{}
+
+func (osSource) Lookup(key string) (string, bool) { return os.LookupEnv(key) }
+func (osSource) FS() fs.FS                        { return osFS{} }
+
+// osFS opens host paths as is, it does not restrict them to fs.ValidPath
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (osFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+// MapSource returns a source backed by {vars}. `_FILE` paths are read from
+// {files} relative to its root, a leading slash is ignored so that
+// "/run/secrets/db" reads "run/secrets/db". A nil {files} contains no file.
+func MapSource(vars map[string]string, files fs.FS) Source {
+	if files == nil {
+		files = emptyFS{}
+	}
+	return mapSource{vars: vars, files: files}
+}
+
+type mapSource struct {
+	vars  map[string]string
+	files fs.FS
+}
+
+func (s mapSource) Lookup(key string) (string, bool) {
+	v, ok := s.vars[key]
+	return v, ok
+}
+func (s mapSource) FS() fs.FS { return s.files }
+
+// Layers returns a source made of {sources} by order of precedence. A
+// variable and its `_FILE` variant are resolved together from the first
+// source that sets either of them, so that a lower source never shadows a
+// higher one.
+func Layers(sources ...Source) Source {
+	return layers(sources)
+}
+
+type layers []Source
+
+// Lookup returns the raw variable from the first source that sets it
+func (l layers) Lookup(key string) (string, bool) {
+	for _, src := range l {
+		if v, ok := src.Lookup(key); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// FS returns the file system of the first source
+func (l layers) FS() fs.FS {
+	if len(l) == 0 {
+		return emptyFS{}
+	}
+	return l[0].FS()
+}
+
+func (l layers) sources() []Source { return l }
+
+func (l layers) watchPaths() []string {
+	var paths []string
+	for _, src := range l {
+		if r, ok := src.(reloader); ok {
+			paths = append(paths, r.watchPaths()...)
+		}
+	}
+	return paths
+}
+
+func (l layers) reload() (Source, error) {
+	reloaded := make(layers, len(l))
+	for i, src := range l {
+		reloaded[i] = src
+		if r, ok := src.(reloader); ok {
+			var err error
+			if reloaded[i], err = r.reload(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return reloaded, nil
+}
+
+// layered is implemented by sources made of other sources by order of
+// precedence
+type layered interface {
+	sources() []Source
+}
+
+// reloader is implemented by sources read from host files once, e.g. dotenv
+// files
+type reloader interface {
+	// watchPaths returns the host paths the source has been read from
+	watchPaths() []string
+	// reload returns a new source with the current content of the files
+	reload() (Source, error)
+}
+
+// emptyFS contains no file
+type emptyFS struct{}
+
+func (emptyFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// fsPath converts a `_FILE` path into a name of {fsys}. Host paths are kept
+// as is for the OS file system.
+func fsPath(fsys fs.FS, name string) string {
+	if _, ok := fsys.(osFS); ok {
+		return name
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
@@ -0,0 +1,101 @@
+package env_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+func TestMapSource(t *testing.T) {
+	t.Parallel()
+
+	files := fstest.MapFS{
+		"run/secrets/password": {Data: []byte("secret")},
+	}
+
+	tt := []struct {
+		name   string
+		key    string
+		vars   map[string]string
+		expect string
+		ok     bool
+	}{
+		{
+			name:   "env ok",
+			key:    "KEY",
+			vars:   map[string]string{"KEY": "value"},
+			expect: "value",
+			ok:     true,
+		},
+		{
+			name: "env unset",
+			key:  "KEY",
+			vars: map[string]string{},
+		},
+		{
+			name:   "absolute file ok",
+			key:    "KEY",
+			vars:   map[string]string{"KEY_FILE": "/run/secrets/password"},
+			expect: "secret",
+			ok:     true,
+		},
+		{
+			name:   "relative file ok",
+			key:    "KEY",
+			vars:   map[string]string{"KEY_FILE": "run/secrets/password"},
+			expect: "secret",
+			ok:     true,
+		},
+		{
+			name: "file not found",
+			key:  "KEY",
+			vars: map[string]string{"KEY_FILE": "/run/secrets/other"},
+		},
+		{
+			name: "file empty",
+			key:  "KEY",
+			vars: map[string]string{"KEY_FILE": ""},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := env.Read(tc.key, env.WithSource(env.MapSource(tc.vars, files)))
+			require.Equal(t, tc.expect, got)
+			require.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestMapSource_NoFiles(t *testing.T) {
+	t.Parallel()
+
+	src := env.MapSource(map[string]string{"KEY_FILE": "/run/secrets/password"}, nil)
+	_, ok := env.Read("KEY", env.WithSource(src))
+	require.False(t, ok)
+}
+
+func TestReadStruct_Source(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Host     string `env:"HOST,required"`
+		Port     int    `env:"PORT,default=5432"`
+		Password string `env:"PASSWORD,required"`
+	}
+
+	src := env.MapSource(map[string]string{
+		"HOST":          "localhost",
+		"PASSWORD_FILE": "/run/secrets/password",
+	}, fstest.MapFS{
+		"run/secrets/password": {Data: []byte("secret")},
+	})
+
+	var cfg config
+	require.NoError(t, env.ReadStruct(&cfg, env.WithSource(src)))
+	require.Equal(t, config{Host: "localhost", Port: 5432, Password: "secret"}, cfg)
+}
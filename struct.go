@@ -3,57 +3,21 @@ package env
 import (
 	"errors"
 	"fmt"
-	"log/slog"
 	"reflect"
-	"strconv"
-	"strings"
-	"time"
+	"slices"
 )
 
-// DecoderFn decodes a string value into a specific type
-type DecoderFn func(raw string) (any, error)
-
-var decoders = map[string]DecoderFn{
-	"string":        func(raw string) (any, error) { return raw, nil },
-	"[]uint8":       func(raw string) (any, error) { return []byte(raw), nil }, // []byte
-	"[]string":      func(raw string) (any, error) { return strings.Split(raw, ","), nil },
-	"int":           func(raw string) (any, error) { v, err := strconv.ParseInt(raw, 10, 64); return int(v), err },
-	"int8":          func(raw string) (any, error) { v, err := strconv.ParseInt(raw, 10, 8); return int8(v), err },
-	"int16":         func(raw string) (any, error) { v, err := strconv.ParseInt(raw, 10, 16); return int16(v), err },
-	"int32":         func(raw string) (any, error) { v, err := strconv.ParseInt(raw, 10, 32); return int32(v), err },
-	"int64":         func(raw string) (any, error) { v, err := strconv.ParseInt(raw, 10, 64); return int64(v), err },
-	"uint":          func(raw string) (any, error) { v, err := strconv.ParseUint(raw, 10, 64); return uint(v), err },
-	"uint8":         func(raw string) (any, error) { v, err := strconv.ParseUint(raw, 10, 8); return uint8(v), err },
-	"uint16":        func(raw string) (any, error) { v, err := strconv.ParseUint(raw, 10, 16); return uint16(v), err },
-	"uint32":        func(raw string) (any, error) { v, err := strconv.ParseUint(raw, 10, 32); return uint32(v), err },
-	"uint64":        func(raw string) (any, error) { v, err := strconv.ParseUint(raw, 10, 64); return uint64(v), err },
-	"float32":       func(raw string) (any, error) { v, err := strconv.ParseFloat(raw, 32); return float32(v), err },
-	"float64":       func(raw string) (any, error) { v, err := strconv.ParseFloat(raw, 64); return float64(v), err },
-	"bool":          func(raw string) (any, error) { v, err := strconv.ParseBool(raw); return bool(v), err },
-	"time.Time":     func(raw string) (any, error) { return time.Parse(time.RFC3339, raw) },
-	"time.Duration": func(raw string) (any, error) { return time.ParseDuration(raw) },
-	"slog.Level": func(raw string) (any, error) {
-		switch strings.TrimSpace(strings.ToLower(raw)) {
-		case "debug":
-			return slog.LevelDebug, nil
-		case "warn":
-			return slog.LevelWarn, nil
-		case "error":
-			return slog.LevelError, nil
-		case "info":
-			return slog.LevelInfo, nil
-		default:
-			return slog.LevelInfo, fmt.Errorf("invalid slog.Level: %q", raw)
-		}
-	},
-}
-
 // ReadStruct fills the fields of a struct with the values from the environment
 // Struct tags are defined as :
 // - `env:"key"`
 // - `env:"key,required"` : if the environment variable is not set, an error is
 // returned
-func ReadStruct(v any) error {
+//
+// Nested structs and pointers to structs without an `env` tag are decoded
+// recursively when they have an `envPrefix` tag or fields bound to variables.
+// The optional `envPrefix:"PREFIX_"` tag is prepended to the keys of every
+// nested field ; prefixes are joined down the tree.
+func ReadStruct(v any, opts ...Option) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return ErrNotPtr
@@ -64,101 +28,309 @@ func ReadStruct(v any) error {
 		return ErrNotStructPtr
 	}
 
-	rt := rv.Type()
+	r := &structReader{opts: newOptions(opts)}
+	r.readStruct(rv, r.opts.prefix, "")
+	if r.opts.explain != nil {
+		*r.opts.explain = r.explanations
+	}
+	if len(r.errs) > 0 {
+		return r.errs
+	}
+	return nil
+}
+
+// ReadStructPrefix is ReadStruct with the keys of every field prefixed by
+// {prefix}, see WithPrefix
+func ReadStructPrefix(prefix string, v any, opts ...Option) error {
+	return ReadStruct(v, append(slices.Clip(opts), WithPrefix(prefix))...)
+}
+
+// structReader holds the state of a single ReadStruct call
+type structReader struct {
+	opts *options
+	// parents are the struct types being decoded, used to stop recursion on
+	// self-referencing types
+	parents []reflect.Type
+	// errs accumulates the errors of every failing field
+	errs Errors
+	// explanations are collected for WithExplain
+	explanations []Explanation
+}
+
+// fail records the error of the field at {path} bound to the env {key}
+func (r *structReader) fail(path, key string, err error) {
+	r.errs = append(r.errs, &FieldError{Field: path, Key: key, Err: err})
+}
+
+// readStruct fills the fields of the struct {rv}. {prefix} is prepended to
+// every env key and {path} to every field name. It returns whether at least
+// one field is set in the environment, whether it could be decoded or not ;
+// defaults do not count.
+func (r *structReader) readStruct(rv reflect.Value, prefix, path string) bool {
+	var (
+		rt  = rv.Type()
+		set = false
+	)
+	r.parents = append(r.parents, rt)
+	defer func() { r.parents = r.parents[:len(r.parents)-1] }()
+
 	for i := 0; i < rt.NumField(); i++ {
 		field := rt.Field(i)
 		fieldValue := rv.Field(i)
+		name := path + field.Name
 
 		if !fieldValue.CanSet() {
-			return fmt.Errorf("field %q: %w", field.Name, ErrFieldUnexported)
+			// nested structs may hold private state, only the fields of the
+			// struct passed to ReadStruct must all be exported
+			if path == "" {
+				r.fail(name, "", ErrFieldUnexported)
+			}
+			continue
 		}
 
-		decoded, err := decodeField(field)
-		if errors.Is(err, ErrFieldNoEnvTag) {
+		if r.opts.isNested(field) {
+			set = r.readNested(fieldValue, prefix+field.Tag.Get("envPrefix"), name+".") || set
 			continue
 		}
+
+		rawTag := field.Tag.Get("env")
+		if rawTag == "" {
+			continue
+		}
+		tag, err := parseTag(rawTag)
+		key := prefix + tag.key
 		if err != nil {
-			return fmt.Errorf("field %q: %w", field.Name, err)
+			if tag.key == "" {
+				key = ""
+			}
+			r.fail(name, key, err)
+			continue
+		}
+
+		decoded, origin, err := decodeField(field.Type, tag, prefix, r.opts)
+		r.explain(name, prefix, tag, origin, err)
+		if origin.Kind == OriginEnv || origin.Kind == OriginFile {
+			set = true
+		}
+		if err != nil {
+			r.fail(name, key, err)
+			continue
 		}
 
 		// skip decoded nil (not set and not required)
 		if decoded == nil {
 			continue
 		}
+		if err := setField(fieldValue, decoded); err != nil {
+			r.fail(name, key, err)
+		}
+	}
 
-		decodedValue := reflect.ValueOf(decoded)
+	return set
+}
 
-		switch field.Type.Kind() {
-		case reflect.Slice:
-			if !decodedValue.IsValid() || decodedValue.Kind() != reflect.Slice {
-				return fmt.Errorf("field %q: %w: cannot convert to slice", field.Name, ErrFieldDecode)
-			}
-			// Always create a new slice with the correct size to avoid index out of bounds
-			fieldValue.Set(reflect.MakeSlice(field.Type, decodedValue.Len(), decodedValue.Len()))
+// setField stores a decoded value into a field
+func setField(fieldValue reflect.Value, decoded any) error {
+	decodedValue := reflect.ValueOf(decoded)
 
-			for i := 0; i < decodedValue.Len(); i++ {
-				fieldValue.Index(i).Set(decodedValue.Index(i))
-			}
-		case reflect.Ptr:
-			if fieldValue.IsNil() {
-				fieldValue.Set(reflect.New(field.Type.Elem()))
-			}
-			if decodedValue.IsValid() {
-				fieldValue.Elem().Set(decodedValue)
-			}
-		default:
-			if decodedValue.IsValid() {
-				fieldValue.Set(decodedValue)
-			}
-		}
+	target := fieldValue.Type()
+	if target.Kind() == reflect.Ptr || target.Kind() == reflect.Slice {
+		target = target.Elem()
 	}
 
+	switch fieldValue.Kind() {
+	case reflect.Slice:
+		if !decodedValue.IsValid() || decodedValue.Kind() != reflect.Slice {
+			return fmt.Errorf("%w: cannot convert to slice", ErrFieldDecode)
+		}
+		if !decodedValue.Type().Elem().AssignableTo(target) {
+			return fmt.Errorf("%w: cannot assign %s to %s", ErrFieldDecode, decodedValue.Type(), fieldValue.Type())
+		}
+		// Always create a new slice with the correct size to avoid index out of bounds
+		fieldValue.Set(reflect.MakeSlice(fieldValue.Type(), decodedValue.Len(), decodedValue.Len()))
+
+		for i := 0; i < decodedValue.Len(); i++ {
+			fieldValue.Index(i).Set(decodedValue.Index(i))
+		}
+	case reflect.Ptr:
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+		}
+		if decodedValue.IsValid() && !decodedValue.Type().AssignableTo(target) {
+			return fmt.Errorf("%w: cannot assign %s to %s", ErrFieldDecode, decodedValue.Type(), target)
+		}
+		if decodedValue.IsValid() {
+			fieldValue.Elem().Set(decodedValue)
+		}
+	default:
+		if decodedValue.IsValid() && !decodedValue.Type().AssignableTo(target) {
+			return fmt.Errorf("%w: cannot assign %s to %s", ErrFieldDecode, decodedValue.Type(), target)
+		}
+		if decodedValue.IsValid() {
+			fieldValue.Set(decodedValue)
+		}
+	}
 	return nil
 }
 
-func decodeField(field reflect.StructField) (any, error) {
-	tag := field.Tag.Get("env")
-	if tag == "" {
-		return nil, ErrFieldNoEnvTag
+// isNested returns whether a field is a struct, or a pointer to a struct, that
+// must be decoded recursively: it has no env tag and no decoder, and it opts in
+// with an `envPrefix` tag or fields bound to variables. Other struct fields,
+// e.g. a *slog.Logger or a sync.Mutex, are left as is.
+func (o *options) isNested(field reflect.StructField) bool {
+	t, ok := o.nestedType(field)
+	if !ok {
+		return false
+	}
+	if _, prefixed := field.Tag.Lookup("envPrefix"); prefixed {
+		return true
 	}
+	return o.hasEnvFields(t, nil)
+}
 
-	// parse tag
-	parts := strings.Split(tag, ",")
-	envName := parts[0]
-	required := false
-	if len(parts) > 1 && parts[1] == "required" {
-		required = true
+// nestedType returns the struct type of an untagged struct or struct pointer
+// field without decoder
+func (o *options) nestedType(field reflect.StructField) (reflect.Type, bool) {
+	if _, tagged := field.Tag.Lookup("env"); tagged {
+		return nil, false
+	}
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, false
 	}
+	if _, decodable := o.fieldDecoder(t, tag{}); decodable {
+		return nil, false
+	}
+	return t, true
+}
 
-	// read the value
-	raw, set := Read(envName)
-	if !set {
-		if required {
-			return nil, fmt.Errorf("%w (%s)", ErrFieldRequired, envName)
+// hasEnvFields returns whether the struct type {t}, or one of its nested
+// structs, has exported fields with an `env` or `envPrefix` tag. {parents} are
+// the types being walked, to stop on self-referencing types.
+func (o *options) hasEnvFields(t reflect.Type, parents []reflect.Type) bool {
+	if slices.Contains(parents, t) {
+		return false
+	}
+	parents = append(parents, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if _, tagged := field.Tag.Lookup("env"); tagged {
+			return true
+		}
+		if _, prefixed := field.Tag.Lookup("envPrefix"); prefixed {
+			return true
+		}
+		if nested, ok := o.nestedType(field); ok && o.hasEnvFields(nested, parents) {
+			return true
 		}
-		return nil, nil
 	}
+	return false
+}
 
-	typeName := field.Type.String()
-	if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() != reflect.Invalid {
-		// For pointers, use the underlying type's decoder
-		typeName = field.Type.Elem().String()
+// readNested decodes a nested struct field. Nil pointers to structs are only
+// allocated when at least one of their fields has a value, the errors of their
+// fields are dropped otherwise except for invalid tags and types.
+func (r *structReader) readNested(fieldValue reflect.Value, prefix, path string) bool {
+	if fieldValue.Kind() != reflect.Ptr {
+		return r.readStruct(fieldValue, prefix, path)
+	}
+	if !fieldValue.IsNil() {
+		return r.readStruct(fieldValue.Elem(), prefix, path)
 	}
-	if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() != reflect.Invalid {
-		typeName = `[]` + field.Type.Elem().String()
+	// a nil pointer to a parent type would recurse forever
+	if slices.Contains(r.parents, fieldValue.Type().Elem()) {
+		return false
 	}
 
-	// decode
-	for name, decoder := range decoders {
-		if name != typeName {
-			continue
+	// read into a scratch reader so that an optional struct without any
+	// variable does not report its required fields
+	scratch := &structReader{opts: r.opts, parents: slices.Clip(r.parents)}
+	nested := reflect.New(fieldValue.Type().Elem())
+	if !scratch.readStruct(nested.Elem(), prefix, path) {
+		for _, err := range scratch.errs {
+			if errors.Is(err, ErrFieldTag) || errors.Is(err, ErrFieldUnsupported) || errors.Is(err, ErrFieldDefault) {
+				r.errs = append(r.errs, err)
+			}
 		}
+		return false
+	}
+	r.errs = append(r.errs, scratch.errs...)
+	r.explanations = append(r.explanations, scratch.explanations...)
+	fieldValue.Set(nested)
+	return true
+}
+
+// decodeField reads and decodes the value of the field of type {t} bound to
+// {tag}, its keys prefixed by {prefix}, along with where it comes from. It
+// returns nil when the variable is not set and has no default.
+func decodeField(t reflect.Type, tag tag, prefix string, o *options) (any, Origin, error) {
+	key := prefix + tag.key
+	if t.Kind() == reflect.Ptr {
+		// For pointers, use the underlying type's decoder
+		t = t.Elem()
+	}
 
-		decoded, err := decoder(raw)
+	decoder, ok := o.fieldDecoder(t, tag)
+	if !ok {
+		return nil, Origin{}, fmt.Errorf("%w: %q", ErrFieldUnsupported, t.String())
+	}
+	if err := o.checkRules(tag.rules, t, decoder); err != nil {
+		return nil, Origin{}, err
+	}
+
+	// decode the default value even when unused so that invalid defaults are
+	// always reported
+	var def any
+	if tag.hasDefault {
+		rawDef := tag.def
+		var err error
+		if tag.expand {
+			if rawDef, err = o.expand(rawDef, key); err != nil {
+				return nil, Origin{}, fmt.Errorf("%w: %w", ErrFieldDefault, err)
+			}
+		}
+		def, err = decoder(rawDef)
 		if err != nil {
-			return nil, fmt.Errorf("%w: %w", ErrFieldDecode, err)
+			return nil, Origin{}, fmt.Errorf("%w: %w", ErrFieldDefault, err)
 		}
-		return decoded, nil
+		if err := o.validate(tag.rules, decoder, rawDef, def); err != nil {
+			return nil, Origin{}, fmt.Errorf("%w: %w", ErrFieldDefault, err)
+		}
+	}
+
+	// read the value
+	raw, origin, err := o.lookupField(tag, prefix)
+	if err != nil {
+		return nil, origin, fmt.Errorf("%w: %w", ErrFieldFile, err)
+	}
+	if origin.Kind == OriginUnset {
+		if tag.required {
+			return nil, origin, ErrFieldRequired
+		}
+		if tag.hasDefault {
+			origin.Kind = OriginDefault
+		}
+		return def, origin, nil
+	}
+	if tag.expand {
+		if raw, err = o.expand(raw, key); err != nil {
+			return nil, origin, err
+		}
+	}
+
+	decoded, err := decoder(raw)
+	if err != nil {
+		return nil, origin, fmt.Errorf("%w: %w", ErrFieldDecode, err)
+	}
+	if err := o.validate(tag.rules, decoder, raw, decoded); err != nil {
+		return nil, origin, err
 	}
-	return nil, fmt.Errorf("%w: %q", ErrFieldUnsupported, typeName)
+	return decoded, origin, nil
 }
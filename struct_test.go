@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -490,3 +491,511 @@ func TestReadStruct_SliceNilValue(t *testing.T) {
 	require.Nil(t, config.OptionalSlice) // Should remain nil
 	require.Equal(t, []string{"val1", "val2"}, config.RequiredSlice)
 }
+
+func TestReadStruct_Nested(t *testing.T) {
+	type dbConfig struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT"`
+	}
+	type redisConfig struct {
+		Addr string `env:"ADDR"`
+	}
+	type cacheConfig struct {
+		Redis redisConfig `envPrefix:"REDIS_"`
+	}
+	type config struct {
+		Name    string       `env:"NAME"`
+		DB      dbConfig     `envPrefix:"DB_"`
+		Replica *dbConfig    `envPrefix:"REPLICA_"`
+		Cache   *cacheConfig `envPrefix:"CACHE_"`
+		Shared  dbConfig
+		Skipped time.Time
+	}
+
+	t.Run("nominal", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("NAME", "app")
+		os.Setenv("DB_HOST", "db")
+		os.Setenv("DB_PORT", "5432")
+		os.Setenv("REPLICA_HOST", "replica")
+		os.Setenv("CACHE_REDIS_ADDR", "redis:6379")
+		os.Setenv("HOST", "shared")
+
+		var cfg config
+		require.NoError(t, env.ReadStruct(&cfg))
+		require.Equal(t, "app", cfg.Name)
+		require.Equal(t, dbConfig{Host: "db", Port: 5432}, cfg.DB)
+		require.NotNil(t, cfg.Replica)
+		require.Equal(t, dbConfig{Host: "replica"}, *cfg.Replica)
+		require.NotNil(t, cfg.Cache)
+		require.Equal(t, "redis:6379", cfg.Cache.Redis.Addr)
+		require.Equal(t, dbConfig{Host: "shared"}, cfg.Shared)
+	})
+
+	t.Run("nil pointer stays nil when nothing is set", func(t *testing.T) {
+		type optional struct {
+			Cache *cacheConfig `envPrefix:"CACHE_"`
+		}
+		os.Clearenv()
+
+		var cfg optional
+		require.NoError(t, env.ReadStruct(&cfg))
+		require.Nil(t, cfg.Cache)
+	})
+
+	t.Run("unset pointer ignores its required fields", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_HOST", "db")
+		os.Setenv("HOST", "shared")
+
+		var cfg config
+		require.NoError(t, env.ReadStruct(&cfg))
+		require.Nil(t, cfg.Replica)
+	})
+
+	t.Run("defaults do not allocate pointers", func(t *testing.T) {
+		type replica struct {
+			Host string `env:"HOST"`
+			Port int    `env:"PORT,default=5432"`
+		}
+		type optional struct {
+			Replica *replica `envPrefix:"REPLICA_"`
+		}
+		os.Clearenv()
+
+		var cfg optional
+		require.NoError(t, env.ReadStruct(&cfg))
+		require.Nil(t, cfg.Replica)
+
+		os.Setenv("REPLICA_HOST", "replica")
+		require.NoError(t, env.ReadStruct(&cfg))
+		require.Equal(t, &replica{Host: "replica", Port: 5432}, cfg.Replica)
+	})
+
+	t.Run("set pointer reports its required fields", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_HOST", "db")
+		os.Setenv("HOST", "shared")
+		os.Setenv("REPLICA_PORT", "5433")
+
+		var cfg config
+		err := env.ReadStruct(&cfg)
+		require.ErrorIs(t, err, env.ErrFieldRequired)
+		require.ErrorContains(t, err, "REPLICA_HOST")
+	})
+
+	t.Run("nested required field fails with its path", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("HOST", "shared")
+		os.Setenv("REPLICA_HOST", "replica")
+
+		var cfg config
+		err := env.ReadStruct(&cfg)
+		require.ErrorIs(t, err, env.ErrFieldRequired)
+		require.ErrorContains(t, err, `"DB.Host"`)
+		require.ErrorContains(t, err, "DB_HOST")
+	})
+
+	t.Run("self-referencing type", func(t *testing.T) {
+		type node struct {
+			Name string `env:"NAME"`
+			Next *node  `envPrefix:"NEXT_"`
+		}
+		os.Clearenv()
+		os.Setenv("NAME", "root")
+
+		var cfg node
+		require.NoError(t, env.ReadStruct(&cfg))
+		require.Equal(t, "root", cfg.Name)
+		require.Nil(t, cfg.Next)
+	})
+
+	t.Run("untagged structs without env fields are left as is", func(t *testing.T) {
+		type state struct {
+			Count int
+			name  string
+		}
+		type withPrivate struct {
+			Host  string `env:"HOST"`
+			cache map[string]string
+		}
+		type service struct {
+			Port    int `env:"PORT"`
+			Logger  *slog.Logger
+			Mu      sync.Mutex
+			State   state
+			Private withPrivate `envPrefix:"PRIVATE_"`
+		}
+		os.Clearenv()
+		os.Setenv("PORT", "8080")
+		os.Setenv("PRIVATE_HOST", "private")
+
+		logger := slog.Default()
+		cfg := service{Logger: logger}
+		require.NoError(t, env.ReadStruct(&cfg))
+		require.Equal(t, 8080, cfg.Port)
+		require.Same(t, logger, cfg.Logger)
+		require.Equal(t, "private", cfg.Private.Host)
+
+		vars, err := env.Describe(&cfg)
+		require.NoError(t, err)
+		require.Len(t, vars, 2)
+	})
+}
+
+func TestReadStruct_Default(t *testing.T) {
+	type defaults struct {
+		Port    int           `env:"PORT,default=8080"`
+		Timeout time.Duration `env:"TIMEOUT,default=5s"`
+		Tags    []string      `env:"TAGS,default=a\\,b"`
+		Name    *string       `env:"NAME,default=app"`
+	}
+	type invalidDefault struct {
+		Port int `env:"PORT,default=abc"`
+	}
+	type requiredDefault struct {
+		Port int `env:"PORT,required,default=8080"`
+	}
+	type unknownOption struct {
+		Port int `env:"PORT,requried"`
+	}
+
+	name := "app"
+	tt := []struct {
+		name     string
+		receiver any
+		env      map[string]string
+		expect   any
+		err      error
+	}{
+		{
+			name:     "unset uses defaults",
+			receiver: &defaults{},
+			expect:   &defaults{Port: 8080, Timeout: 5 * time.Second, Tags: []string{"a", "b"}, Name: &name},
+		},
+		{
+			name:     "set overrides defaults",
+			receiver: &defaults{},
+			env:      map[string]string{"PORT": "80", "TIMEOUT": "1m", "TAGS": "c", "NAME": "app"},
+			expect:   &defaults{Port: 80, Timeout: time.Minute, Tags: []string{"c"}, Name: &name},
+		},
+		{
+			name:     "invalid default fails",
+			receiver: &invalidDefault{},
+			err:      env.ErrFieldDefault,
+		},
+		{
+			name:     "invalid default fails even when set",
+			receiver: &invalidDefault{},
+			env:      map[string]string{"PORT": "80"},
+			err:      env.ErrFieldDefault,
+		},
+		{
+			name:     "required with default fails",
+			receiver: &requiredDefault{},
+			err:      env.ErrFieldTag,
+		},
+		{
+			name:     "unknown option is ignored",
+			receiver: &unknownOption{},
+			env:      map[string]string{"PORT": "80"},
+			expect:   &unknownOption{Port: 80},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			for k, v := range tc.env {
+				os.Setenv(k, v)
+			}
+
+			err := env.ReadStruct(tc.receiver)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, tc.receiver)
+		})
+	}
+}
+
+func TestReadStruct_Errors(t *testing.T) {
+	type config struct {
+		Host    string `env:"HOST,required"`
+		Port    int    `env:"PORT"`
+		Any     any    `env:"ANY"`
+		Name    string `env:"NAME"`
+		Timeout int    `env:"TIMEOUT,required"`
+	}
+
+	os.Clearenv()
+	os.Setenv("PORT", "abc")
+	os.Setenv("ANY", "value")
+	os.Setenv("NAME", "app")
+
+	var cfg config
+	err := env.ReadStruct(&cfg)
+	require.Error(t, err)
+	require.ErrorIs(t, err, env.ErrFieldRequired)
+	require.ErrorIs(t, err, env.ErrFieldDecode)
+	require.ErrorIs(t, err, env.ErrFieldUnsupported)
+
+	var errs env.Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 4)
+
+	expect := []struct {
+		field string
+		key   string
+		err   error
+	}{
+		{field: "Host", key: "HOST", err: env.ErrFieldRequired},
+		{field: "Port", key: "PORT", err: env.ErrFieldDecode},
+		{field: "Any", key: "ANY", err: env.ErrFieldUnsupported},
+		{field: "Timeout", key: "TIMEOUT", err: env.ErrFieldRequired},
+	}
+	for i, e := range expect {
+		require.Equal(t, e.field, errs[i].Field)
+		require.Equal(t, e.key, errs[i].Key)
+		require.ErrorIs(t, errs[i], e.err)
+	}
+
+	var fieldErr *env.FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	require.Equal(t, "Host", fieldErr.Field)
+
+	var envErr env.Err
+	require.ErrorAs(t, err, &envErr)
+	require.Equal(t, env.ErrFieldRequired, envErr)
+
+	// valid fields are still decoded
+	require.Equal(t, "app", cfg.Name)
+}
+
+func TestReadStruct_Map(t *testing.T) {
+	t.Parallel()
+
+	type labels map[string]string
+	type config struct {
+		Labels    labels                   `env:"LABELS"`
+		Limits    map[string]int           `env:"LIMITS"`
+		Timeouts  map[string]time.Duration `env:"TIMEOUTS,sep=;,kvsep=="`
+		Features  map[string]struct{}      `env:"FEATURES"`
+		Ports     map[int]bool             `env:"PORTS"`
+		Empty     map[string]string        `env:"EMPTY"`
+		Defaulted map[string]int           `env:"DEFAULTED,default=a:1\\,b:2"`
+	}
+	type unsupported struct {
+		Field map[string]any `env:"FIELD"`
+	}
+	type sameSeparators struct {
+		Field map[string]string `env:"FIELD,sep=:"`
+	}
+
+	tt := []struct {
+		name     string
+		receiver any
+		vars     map[string]string
+		expect   any
+		err      error
+	}{
+		{
+			name:     "nominal",
+			receiver: &config{},
+			vars: map[string]string{
+				"LABELS":   "team:core, env : prod",
+				"LIMITS":   "tenant-a:10,tenant-b:20",
+				"TIMEOUTS": "read=1s;write=2s",
+				"FEATURES": "a,b",
+				"PORTS":    "80:true,443:false",
+				"EMPTY":    "",
+			},
+			expect: &config{
+				Labels:    labels{"team": "core", "env": "prod"},
+				Limits:    map[string]int{"tenant-a": 10, "tenant-b": 20},
+				Timeouts:  map[string]time.Duration{"read": time.Second, "write": 2 * time.Second},
+				Features:  map[string]struct{}{"a": {}, "b": {}},
+				Ports:     map[int]bool{80: true, 443: false},
+				Empty:     map[string]string{},
+				Defaulted: map[string]int{"a": 1, "b": 2},
+			},
+		},
+		{
+			name:     "missing key value separator",
+			receiver: &config{},
+			vars:     map[string]string{"LABELS": "team"},
+			err:      env.ErrFieldDecode,
+		},
+		{
+			name:     "invalid value",
+			receiver: &config{},
+			vars:     map[string]string{"LIMITS": "tenant-a:abc"},
+			err:      env.ErrFieldDecode,
+		},
+		{
+			name:     "invalid key",
+			receiver: &config{},
+			vars:     map[string]string{"PORTS": "http:true"},
+			err:      env.ErrFieldDecode,
+		},
+		{
+			name:     "unsupported value type",
+			receiver: &unsupported{},
+			vars:     map[string]string{"FIELD": "a:b"},
+			err:      env.ErrFieldUnsupported,
+		},
+		{
+			name:     "same separators",
+			receiver: &sameSeparators{},
+			err:      env.ErrFieldTag,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := env.ReadStruct(tc.receiver, env.WithSource(env.MapSource(tc.vars, nil)))
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, tc.receiver)
+		})
+	}
+}
+
+func TestReadStruct_List(t *testing.T) {
+	t.Parallel()
+
+	type hosts []string
+	type stringList struct {
+		Field []string `env:"FIELD"`
+	}
+	type ints struct {
+		Field []int `env:"FIELD"`
+	}
+	type durations struct {
+		Field []time.Duration `env:"FIELD,sep=;"`
+	}
+	type named struct {
+		Field hosts `env:"FIELD,sep= "`
+	}
+	type array struct {
+		Field [3]uint8 `env:"FIELD"`
+	}
+	type unsupported struct {
+		Field []chan int `env:"FIELD"`
+	}
+
+	tt := []struct {
+		name     string
+		receiver any
+		raw      string
+		expect   any
+		err      error
+	}{
+		{
+			name:     "whitespace is trimmed",
+			receiver: &stringList{},
+			raw:      " a , b c ,d ",
+			expect:   &stringList{Field: []string{"a", "b c", "d"}},
+		},
+		{
+			name:     "empty input is an empty list",
+			receiver: &stringList{},
+			raw:      "  ",
+			expect:   &stringList{Field: []string{}},
+		},
+		{
+			name:     "empty items are kept",
+			receiver: &stringList{},
+			raw:      "a,,b",
+			expect:   &stringList{Field: []string{"a", "", "b"}},
+		},
+		{
+			name:     "escaped separator",
+			receiver: &stringList{},
+			raw:      `a\,b,c\\d`,
+			expect:   &stringList{Field: []string{"a,b", `c\d`}},
+		},
+		{
+			name:     "quoted items",
+			receiver: &stringList{},
+			raw:      `"a,b" , ' c ',"d\"e",""`,
+			expect:   &stringList{Field: []string{"a,b", " c ", `d"e`, ""}},
+		},
+		{
+			name:     "unterminated quote",
+			receiver: &stringList{},
+			raw:      `"a,b`,
+			err:      env.ErrFieldDecode,
+		},
+		{
+			name:     "characters after quote",
+			receiver: &stringList{},
+			raw:      `"a"b,c`,
+			err:      env.ErrFieldDecode,
+		},
+		{
+			name:     "ints",
+			receiver: &ints{},
+			raw:      "1, -2,3",
+			expect:   &ints{Field: []int{1, -2, 3}},
+		},
+		{
+			name:     "invalid int item",
+			receiver: &ints{},
+			raw:      "1,a",
+			err:      env.ErrFieldDecode,
+		},
+		{
+			name:     "durations with custom separator",
+			receiver: &durations{},
+			raw:      "1s;2m",
+			expect:   &durations{Field: []time.Duration{time.Second, 2 * time.Minute}},
+		},
+		{
+			name:     "named slice with space separator",
+			receiver: &named{},
+			raw:      "kafka-1:9092 kafka-2:9092",
+			expect:   &named{Field: hosts{"kafka-1:9092", "kafka-2:9092"}},
+		},
+		{
+			name:     "array",
+			receiver: &array{},
+			raw:      "1,2,3",
+			expect:   &array{Field: [3]uint8{1, 2, 3}},
+		},
+		{
+			name:     "array length mismatch",
+			receiver: &array{},
+			raw:      "1,2",
+			err:      env.ErrFieldDecode,
+		},
+		{
+			name:     "unsupported item type",
+			receiver: &unsupported{},
+			raw:      "a",
+			err:      env.ErrFieldUnsupported,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := env.MapSource(map[string]string{"FIELD": tc.raw}, nil)
+			err := env.ReadStruct(tc.receiver, env.WithSource(src))
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, tc.receiver)
+		})
+	}
+}
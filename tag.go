@@ -0,0 +1,153 @@
+package env
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xdrm-io/env/internal/envtag"
+)
+
+// tag is a parsed `env` struct tag
+type tag struct {
+	key        string
+	required   bool
+	def        string
+	hasDefault bool
+	// sep separates the items of lists and the entries of maps, "," when
+	// empty
+	sep string
+	// kvsep separates the key from the value of map entries, ":" when empty
+	kvsep string
+	// rules validate the value once decoded
+	rules rules
+	// secret values must not be displayed
+	secret bool
+	// noFile ignores the `_FILE` variant of the key
+	noFile bool
+	// expand expands the references to variables of the value, see Expand
+	expand bool
+	// aliases are read when the key is not set, by order of precedence
+	aliases []alias
+	// unknown are the options ReadStruct ignores, e.g. the ones of other
+	// libraries sharing the tag ; Describe and Schema reject them
+	unknown []string
+}
+
+// alias is another key a field is read from, e.g. its former name
+type alias struct {
+	key string
+	// deprecated aliases are reported when used
+	deprecated bool
+}
+
+// keys returns the keys the field is read from by order of precedence, the key
+// then its aliases, prefixed by {prefix}
+func (t tag) keys(prefix string) []string {
+	keys := make([]string, 0, 1+len(t.aliases))
+	keys = append(keys, prefix+t.key)
+	for _, a := range t.aliases {
+		keys = append(keys, prefix+a.key)
+	}
+	return keys
+}
+
+// checkUnknown fails when the tag has unknown options
+func (t tag) checkUnknown() error {
+	if len(t.unknown) > 0 {
+		return fmt.Errorf("%w: unknown option %q", ErrFieldTag, t.unknown[0])
+	}
+	return nil
+}
+
+func (t tag) separator() string {
+	if t.sep == "" {
+		return ","
+	}
+	return t.sep
+}
+
+func (t tag) kvSeparator() string {
+	if t.kvsep == "" {
+		return ":"
+	}
+	return t.kvsep
+}
+
+// parseTag parses an `env` struct tag. Options are separated by commas, a
+// literal comma can be escaped as `\,` in option values ; it is written `\\,`
+// inside the struct tag literal.
+func parseTag(raw string) (tag, error) {
+	parts := envtag.Split(raw)
+
+	t := tag{key: parts[0]}
+	if t.key == "" {
+		return t, fmt.Errorf("%w: missing key", ErrFieldTag)
+	}
+
+	for _, part := range parts[1:] {
+		name, value, hasValue := strings.Cut(part, "=")
+		switch {
+		case name == "required" && !hasValue:
+			t.required = true
+		case name == "default" && hasValue:
+			t.def, t.hasDefault = value, true
+		case name == "sep" && value != "":
+			t.sep = value
+		case name == "kvsep" && value != "":
+			t.kvsep = value
+		case name == "min" && value != "":
+			t.rules.min = value
+		case name == "max" && value != "":
+			t.rules.max = value
+		case name == "minlen" && hasValue:
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return t, fmt.Errorf("%w: invalid minlen %q", ErrFieldTag, value)
+			}
+			t.rules.minLen = &n
+		case name == "maxlen" && hasValue:
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return t, fmt.Errorf("%w: invalid maxlen %q", ErrFieldTag, value)
+			}
+			t.rules.maxLen = &n
+		case name == "oneof" && hasValue:
+			t.rules.oneOf = strings.Split(value, "|")
+		case name == "regex" && hasValue:
+			pattern, err := regexp.Compile(value)
+			if err != nil {
+				return t, fmt.Errorf("%w: invalid regex: %w", ErrFieldTag, err)
+			}
+			t.rules.pattern = pattern
+		case name == "secret" && !hasValue:
+			t.secret = true
+		case name == "nofile" && !hasValue:
+			t.noFile = true
+		case name == "expand" && !hasValue:
+			t.expand = true
+		case (name == "alias" || name == "deprecated") && hasValue:
+			for _, key := range strings.Split(value, "|") {
+				if key == "" {
+					return t, fmt.Errorf("%w: missing %s key", ErrFieldTag, name)
+				}
+				t.aliases = append(t.aliases, alias{key: key, deprecated: name == "deprecated"})
+			}
+		case name == "notempty" && !hasValue:
+			t.rules.notEmpty = true
+		case name == "validate" && value != "":
+			t.rules.validators = strings.Split(value, "|")
+		default:
+			t.unknown = append(t.unknown, part)
+		}
+	}
+
+	if t.required && t.hasDefault {
+		return t, fmt.Errorf("%w: required field cannot have a default", ErrFieldTag)
+	}
+	if t.separator() == t.kvSeparator() {
+		return t, fmt.Errorf("%w: sep and kvsep must differ", ErrFieldTag)
+	}
+	return t, nil
+}
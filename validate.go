@@ -0,0 +1,224 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidatorFn validates a decoded value. The value of pointer fields is the
+// pointed value, the value of Secret fields is the value they hold.
+type ValidatorFn func(v any) error
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[string]ValidatorFn{}
+)
+
+// RegisterValidator registers the validator {name} for every subsequent call,
+// fields use it with the `validate=name` tag option. It replaces any
+// validator already registered with that name. It is safe for concurrent use.
+func RegisterValidator(name string, fn ValidatorFn) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators[name] = fn
+}
+
+// validator returns the validator {name}, per-call validators take precedence
+// over the registry
+func (o *options) validator(name string) (ValidatorFn, bool) {
+	if fn, ok := o.validators[name]; ok {
+		return fn, true
+	}
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+	fn, ok := validators[name]
+	return fn, ok
+}
+
+// rules are the validation options of an `env` tag
+type rules struct {
+	// min and max are decoded with the decoder of the field, for numbers and
+	// durations
+	min, max string
+	// minLen and maxLen limit the length of strings, lists and maps
+	minLen, maxLen *int
+	// oneOf lists the allowed raw values
+	oneOf []string
+	// pattern must match the raw value
+	pattern *regexp.Regexp
+	// notEmpty rejects blank raw values
+	notEmpty bool
+	// validators are the names of custom validators
+	validators []string
+}
+
+// checkRules checks that the rules {r} fit the field type {t} decoded by
+// {decoder} and that its validators exist, so that tag mistakes are reported
+// whether or not the variable is set
+func (o *options) checkRules(r rules, t reflect.Type, decoder DecoderFn) error {
+	if valueType, ok := secretValueType(t); ok {
+		t = valueType
+	}
+
+	if r.min != "" || r.max != "" {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+		default:
+			return fmt.Errorf("%w: min and max require a number or a duration", ErrFieldTag)
+		}
+		for _, raw := range []string{r.min, r.max} {
+			if raw == "" {
+				continue
+			}
+			bound, err := decoder(raw)
+			if err != nil {
+				return fmt.Errorf("%w: invalid bound %q: %w", ErrFieldTag, raw, err)
+			}
+			if reflect.ValueOf(unwrapSecret(bound)).Kind() != t.Kind() {
+				return fmt.Errorf("%w: invalid bound %q", ErrFieldTag, raw)
+			}
+		}
+	}
+
+	if r.minLen != nil || r.maxLen != nil {
+		switch t.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		default:
+			return fmt.Errorf("%w: minlen and maxlen require a string, a list or a map", ErrFieldTag)
+		}
+	}
+
+	for _, name := range r.validators {
+		if _, ok := o.validator(name); !ok {
+			return fmt.Errorf("%w: unknown validator %q", ErrFieldTag, name)
+		}
+	}
+	return nil
+}
+
+// validate checks the value {raw} once decoded into {decoded} by {decoder}
+func (o *options) validate(r rules, decoder DecoderFn, raw string, decoded any) error {
+	decoded = unwrapSecret(decoded)
+	if r.notEmpty && strings.TrimSpace(raw) == "" {
+		return ErrFieldEmpty
+	}
+	if r.oneOf != nil && !slices.Contains(r.oneOf, raw) {
+		return fmt.Errorf("%w: %q is not one of %s", ErrFieldOneOf, raw, strings.Join(r.oneOf, ", "))
+	}
+	if r.pattern != nil && !r.pattern.MatchString(raw) {
+		return fmt.Errorf("%w: %q does not match %s", ErrFieldPattern, raw, r.pattern)
+	}
+
+	v := reflect.ValueOf(decoded)
+	if err := validateRange(v, r.min, r.max, decoder); err != nil {
+		return err
+	}
+	if err := validateLength(v, r.minLen, r.maxLen); err != nil {
+		return err
+	}
+
+	for _, name := range r.validators {
+		fn, ok := o.validator(name)
+		if !ok {
+			return fmt.Errorf("%w: unknown validator %q", ErrFieldTag, name)
+		}
+		if err := fn(decoded); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrFieldInvalid, name, err)
+		}
+	}
+	return nil
+}
+
+// validateRange checks that the number or duration {v} is within [min, max],
+// both bounds are decoded with {decoder}
+func validateRange(v reflect.Value, min, max string, decoder DecoderFn) error {
+	if min == "" && max == "" {
+		return nil
+	}
+
+	var cmp func(bound reflect.Value) int
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		cmp = func(bound reflect.Value) int { return compare(v.Int(), bound.Int()) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		cmp = func(bound reflect.Value) int { return compare(v.Uint(), bound.Uint()) }
+	case reflect.Float32, reflect.Float64:
+		cmp = func(bound reflect.Value) int { return compare(v.Float(), bound.Float()) }
+	default:
+		return fmt.Errorf("%w: min and max require a number or a duration", ErrFieldTag)
+	}
+
+	check := func(raw string, fails func(int) bool, format string) error {
+		if raw == "" {
+			return nil
+		}
+		bound, err := decoder(raw)
+		if err != nil {
+			return fmt.Errorf("%w: invalid bound %q: %w", ErrFieldTag, raw, err)
+		}
+		boundValue := reflect.ValueOf(unwrapSecret(bound))
+		if boundValue.Kind() != v.Kind() {
+			return fmt.Errorf("%w: invalid bound %q", ErrFieldTag, raw)
+		}
+		if fails(cmp(boundValue)) {
+			return fmt.Errorf("%w: "+format, ErrFieldRange, formatValue(v), raw)
+		}
+		return nil
+	}
+	if err := check(min, func(c int) bool { return c < 0 }, "%s is lower than %s"); err != nil {
+		return err
+	}
+	return check(max, func(c int) bool { return c > 0 }, "%s is greater than %s")
+}
+
+// validateLength checks that the length of the string, list or map {v} is
+// within [min, max]. The length of strings is their number of runes.
+func validateLength(v reflect.Value, min, max *int) error {
+	if min == nil && max == nil {
+		return nil
+	}
+
+	var length int
+	switch v.Kind() {
+	case reflect.String:
+		length = utf8.RuneCountInString(v.String())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		length = v.Len()
+	default:
+		return fmt.Errorf("%w: minlen and maxlen require a string, a list or a map", ErrFieldTag)
+	}
+
+	if min != nil && length < *min {
+		return fmt.Errorf("%w: length %d is lower than %d", ErrFieldLength, length, *min)
+	}
+	if max != nil && length > *max {
+		return fmt.Errorf("%w: length %d is greater than %d", ErrFieldLength, length, *max)
+	}
+	return nil
+}
+
+func compare[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// formatValue formats numbers and durations for error messages
+func formatValue(v reflect.Value) string {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,161 @@
+package env_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+func TestReadStruct_Validation(t *testing.T) {
+	t.Parallel()
+
+	type port struct {
+		Field int `env:"FIELD,min=1,max=65535"`
+	}
+	type ratio struct {
+		Field float64 `env:"FIELD,min=0,max=1"`
+	}
+	type timeout struct {
+		Field *time.Duration `env:"FIELD,min=1s,max=1m"`
+	}
+	type name struct {
+		Field string `env:"FIELD,minlen=2,maxlen=4"`
+	}
+	type tags struct {
+		Field []string `env:"FIELD,maxlen=2"`
+	}
+	type mode struct {
+		Field string `env:"FIELD,oneof=dev|staging|prod"`
+	}
+	type pattern struct {
+		Field string `env:"FIELD,regex=^[a-z]+-[0-9]{1\\,3}$"`
+	}
+	type notEmpty struct {
+		Field string `env:"FIELD,notempty"`
+	}
+	type invalidDefault struct {
+		Field int `env:"FIELD,default=0,min=1"`
+	}
+	type invalidBound struct {
+		Field int `env:"FIELD,min=abc"`
+	}
+	type invalidRangeType struct {
+		Field string `env:"FIELD,min=1"`
+	}
+	type invalidRegex struct {
+		Field string `env:"FIELD,regex=["`
+	}
+
+	tt := []struct {
+		name     string
+		receiver any
+		vars     map[string]string
+		err      error
+	}{
+		{name: "int within range", receiver: &port{}, vars: map[string]string{"FIELD": "8080"}},
+		{name: "int lower bound", receiver: &port{}, vars: map[string]string{"FIELD": "1"}},
+		{name: "int below range", receiver: &port{}, vars: map[string]string{"FIELD": "0"}, err: env.ErrFieldRange},
+		{name: "int above range", receiver: &port{}, vars: map[string]string{"FIELD": "99999"}, err: env.ErrFieldRange},
+		{name: "float within range", receiver: &ratio{}, vars: map[string]string{"FIELD": "0.5"}},
+		{name: "float above range", receiver: &ratio{}, vars: map[string]string{"FIELD": "1.5"}, err: env.ErrFieldRange},
+		{name: "duration within range", receiver: &timeout{}, vars: map[string]string{"FIELD": "30s"}},
+		{name: "duration below range", receiver: &timeout{}, vars: map[string]string{"FIELD": "10ms"}, err: env.ErrFieldRange},
+		{name: "unset is not validated", receiver: &port{}, vars: map[string]string{}},
+		{name: "string length ok", receiver: &name{}, vars: map[string]string{"FIELD": "abc"}},
+		{name: "string too short", receiver: &name{}, vars: map[string]string{"FIELD": "a"}, err: env.ErrFieldLength},
+		{name: "string too long", receiver: &name{}, vars: map[string]string{"FIELD": "abcde"}, err: env.ErrFieldLength},
+		{name: "list too long", receiver: &tags{}, vars: map[string]string{"FIELD": "a,b,c"}, err: env.ErrFieldLength},
+		{name: "oneof ok", receiver: &mode{}, vars: map[string]string{"FIELD": "prod"}},
+		{name: "oneof fails", receiver: &mode{}, vars: map[string]string{"FIELD": "prodd"}, err: env.ErrFieldOneOf},
+		{name: "regex ok", receiver: &pattern{}, vars: map[string]string{"FIELD": "app-12"}},
+		{name: "regex fails", receiver: &pattern{}, vars: map[string]string{"FIELD": "app-1234"}, err: env.ErrFieldPattern},
+		{name: "notempty ok", receiver: &notEmpty{}, vars: map[string]string{"FIELD": "a"}},
+		{name: "notempty fails", receiver: &notEmpty{}, vars: map[string]string{"FIELD": "  "}, err: env.ErrFieldEmpty},
+		{name: "invalid default", receiver: &invalidDefault{}, vars: map[string]string{}, err: env.ErrFieldDefault},
+		{name: "invalid bound", receiver: &invalidBound{}, vars: map[string]string{"FIELD": "1"}, err: env.ErrFieldTag},
+		{name: "range on string", receiver: &invalidRangeType{}, vars: map[string]string{"FIELD": "1"}, err: env.ErrFieldTag},
+		{name: "range on unset string", receiver: &invalidRangeType{}, vars: map[string]string{}, err: env.ErrFieldTag},
+		{name: "invalid bound unset", receiver: &invalidBound{}, vars: map[string]string{}, err: env.ErrFieldTag},
+		{name: "invalid regex", receiver: &invalidRegex{}, vars: map[string]string{"FIELD": "a"}, err: env.ErrFieldTag},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := env.ReadStruct(tc.receiver, env.WithSource(env.MapSource(tc.vars, nil)))
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.err)
+
+			var fieldErr *env.FieldError
+			require.ErrorAs(t, err, &fieldErr)
+			require.Equal(t, "Field", fieldErr.Field)
+			require.Equal(t, "FIELD", fieldErr.Key)
+		})
+	}
+}
+
+func TestReadStruct_CustomValidator(t *testing.T) {
+	t.Parallel()
+
+	env.RegisterValidator("lowercase", func(v any) error {
+		if s := v.(string); s != strings.ToLower(s) {
+			return errors.New("must be lowercase")
+		}
+		return nil
+	})
+
+	type config struct {
+		Name string `env:"NAME,validate=lowercase"`
+	}
+	type unknown struct {
+		Name string `env:"NAME,validate=missing"`
+	}
+
+	src := env.WithSource(env.MapSource(map[string]string{"NAME": "App"}, nil))
+
+	var cfg config
+	require.ErrorIs(t, env.ReadStruct(&cfg, src), env.ErrFieldInvalid)
+
+	err := env.ReadStruct(&cfg, src, env.WithValidator("lowercase", func(any) error { return nil }))
+	require.NoError(t, err)
+	require.Equal(t, "App", cfg.Name)
+
+	require.ErrorIs(t, env.ReadStruct(&unknown{}, src), env.ErrFieldTag)
+
+	// tag mistakes are reported while the variable is unset
+	unset := env.WithSource(env.MapSource(nil, nil))
+	require.ErrorIs(t, env.ReadStruct(&unknown{}, unset), env.ErrFieldTag)
+	_, err = env.Describe(&unknown{}, unset)
+	require.ErrorIs(t, err, env.ErrFieldTag)
+	require.ErrorContains(t, err, `unknown validator "missing"`)
+}
+
+func TestDescribe_InvalidRules(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Name string `env:"NAME,validate=typo"`
+		Port string `env:"PORT,min=1"`
+		Flag bool   `env:"FLAG,maxlen=1"`
+	}
+
+	_, err := env.Describe(&config{})
+	var errs env.Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 3)
+	for _, fieldErr := range errs {
+		require.ErrorIs(t, fieldErr, env.ErrFieldTag)
+	}
+
+	err = env.ReadStruct(&config{}, env.WithSource(env.MapSource(nil, nil)))
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 3)
+}
@@ -0,0 +1,189 @@
+package env
+
+import (
+	"context"
+	"crypto/sha256"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Watcher keeps a configuration of type T loaded with ReadStruct up to date.
+// It polls the files the configuration depends on: the `_FILE` paths read by
+// ReadStruct and the dotenv files of the source. When one of them changes, it
+// loads a fresh T and publishes it atomically. A failed reload keeps the
+// previous configuration.
+type Watcher[T any] struct {
+	interval time.Duration
+	opts     []Option
+	current  atomic.Pointer[T]
+
+	// mu guards the reload state and the callbacks, it is held while the
+	// callbacks run
+	mu          sync.Mutex
+	source      Source
+	files       map[string]watchedFile
+	subscribers []func(old, new *T)
+	onError     []func(error)
+}
+
+// watchedFile is a file polled by a Watcher, indexed by its path
+type watchedFile struct {
+	fsys fs.FS
+	sum  [sha256.Size]byte
+}
+
+// NewWatcher loads a first configuration with ReadStruct and {opts}, it fails
+// when that configuration cannot be loaded. Files are polled every {interval}
+// once Run is called, it fails with ErrWatchInterval when not positive.
+func NewWatcher[T any](interval time.Duration, opts ...Option) (*Watcher[T], error) {
+	if interval <= 0 {
+		return nil, ErrWatchInterval
+	}
+	w := &Watcher[T]{
+		interval: interval,
+		opts:     opts,
+		source:   newOptions(opts).source,
+	}
+
+	cfg, files, err := w.load(w.source)
+	if err != nil {
+		return nil, err
+	}
+	w.current.Store(cfg)
+	w.files = files
+	return w, nil
+}
+
+// Load returns the current configuration. It must not be modified.
+func (w *Watcher[T]) Load() *T {
+	return w.current.Load()
+}
+
+// Subscribe calls {fn} with the previous and the new configuration after each
+// successful reload. {fn} runs with the watcher lock held: it must not call
+// Reload, Subscribe or OnError, which would deadlock.
+func (w *Watcher[T]) Subscribe(fn func(old, new *T)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// OnError calls {fn} with the error of each failed reload. {fn} runs with the
+// watcher lock held: it must not call Reload, Subscribe or OnError, which
+// would deadlock.
+func (w *Watcher[T]) OnError(fn func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = append(w.onError, fn)
+}
+
+// Run polls the files until {ctx} is done, it then returns the context error
+func (w *Watcher[T]) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+// Reload loads a fresh configuration whether files changed or not. On error,
+// the previous configuration is kept.
+func (w *Watcher[T]) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reload()
+}
+
+// poll reloads the configuration when one of the files changed
+func (w *Watcher[T]) poll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for path, file := range w.files {
+		if checksum(file.fsys, path) != file.sum {
+			w.reload()
+			return
+		}
+	}
+}
+
+// reload loads a fresh configuration, it must be called with mu held
+func (w *Watcher[T]) reload() error {
+	src := w.source
+	if r, ok := src.(reloader); ok {
+		reloaded, err := r.reload()
+		if err != nil {
+			// keep following the same files with their current content, so
+			// that only a new change triggers a new reload
+			for path, file := range w.files {
+				file.sum = checksum(file.fsys, path)
+				w.files[path] = file
+			}
+			w.fail(err)
+			return err
+		}
+		src = reloaded
+	}
+
+	cfg, files, err := w.load(src)
+	// follow the files of the failed attempt too, so that fixing them
+	// triggers a new reload
+	w.files = files
+	if err != nil {
+		w.fail(err)
+		return err
+	}
+
+	w.source = src
+	old := w.current.Swap(cfg)
+	for _, fn := range w.subscribers {
+		fn(old, cfg)
+	}
+	return nil
+}
+
+func (w *Watcher[T]) fail(err error) {
+	for _, fn := range w.onError {
+		fn(err)
+	}
+}
+
+// load reads a fresh configuration from {src} and returns the checksums of
+// the files it depends on
+func (w *Watcher[T]) load(src Source) (*T, map[string]watchedFile, error) {
+	files := make(map[string]watchedFile)
+	watch := func(fsys fs.FS, path string) {
+		files[path] = watchedFile{fsys: fsys, sum: checksum(fsys, path)}
+	}
+	if r, ok := src.(reloader); ok {
+		for _, path := range r.watchPaths() {
+			watch(osFS{}, path)
+		}
+	}
+	onFile := func(o *options) { o.onFile = watch }
+
+	cfg := new(T)
+	err := ReadStruct(cfg, append(w.opts[:len(w.opts):len(w.opts)], WithSource(src), onFile)...)
+	if err != nil {
+		return nil, files, err
+	}
+	return cfg, files, nil
+}
+
+// checksum returns the checksum of the file content, missing and unreadable
+// files have an empty checksum
+func checksum(fsys fs.FS, path string) [sha256.Size]byte {
+	raw, err := fs.ReadFile(fsys, fsPath(fsys, path))
+	if err != nil {
+		return [sha256.Size]byte{}
+	}
+	return sha256.Sum256(raw)
+}
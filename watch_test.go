@@ -0,0 +1,159 @@
+package env_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xdrm-io/env"
+)
+
+func TestWatcher(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Password string `env:"PASSWORD,required"`
+		Port     int    `env:"PORT"`
+	}
+
+	var (
+		dir        = t.TempDir()
+		secretPath = filepath.Join(dir, "password")
+		dotenvPath = filepath.Join(dir, ".env")
+	)
+	require.NoError(t, os.WriteFile(secretPath, []byte("v1"), 0o600))
+	require.NoError(t, os.WriteFile(dotenvPath, []byte("PORT=80"), 0o600))
+
+	base := env.MapSource(map[string]string{"PASSWORD_FILE": "/password"}, os.DirFS(dir))
+	src, err := env.DotenvSource(dotenvPath, base, env.DotenvFill)
+	require.NoError(t, err)
+
+	w, err := env.NewWatcher[config](5*time.Millisecond, env.WithSource(src))
+	require.NoError(t, err)
+	require.Equal(t, &config{Password: "v1", Port: 80}, w.Load())
+
+	var (
+		mu      sync.Mutex
+		changes [][2]config
+		errs    []error
+	)
+	w.Subscribe(func(old, new *config) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, [2]config{*old, *new})
+	})
+	w.OnError(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	defer func() {
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	}()
+
+	// secret file rotation
+	require.NoError(t, os.WriteFile(secretPath, []byte("v2"), 0o600))
+	require.Eventually(t, func() bool { return w.Load().Password == "v2" }, time.Second, time.Millisecond)
+
+	mu.Lock()
+	require.Equal(t, [][2]config{{{Password: "v1", Port: 80}, {Password: "v2", Port: 80}}}, changes)
+	mu.Unlock()
+
+	// dotenv file change
+	require.NoError(t, os.WriteFile(dotenvPath, []byte("PORT=8080"), 0o600))
+	require.Eventually(t, func() bool { return w.Load().Port == 8080 }, time.Second, time.Millisecond)
+
+	// failed reload keeps the previous configuration
+	require.NoError(t, os.WriteFile(dotenvPath, []byte("PORT=abc"), 0o600))
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) == 1
+	}, time.Second, time.Millisecond)
+	mu.Lock()
+	require.ErrorIs(t, errs[0], env.ErrFieldDecode)
+	mu.Unlock()
+	require.Equal(t, &config{Password: "v2", Port: 8080}, w.Load())
+
+	// fixing the file reloads again
+	require.NoError(t, os.WriteFile(dotenvPath, []byte("PORT=443"), 0o600))
+	require.Eventually(t, func() bool { return w.Load().Port == 443 }, time.Second, time.Millisecond)
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Password string `env:"PASSWORD,required"`
+	}
+
+	dir := t.TempDir()
+	base := env.MapSource(map[string]string{"PASSWORD_FILE": "/password"}, os.DirFS(dir))
+
+	_, err := env.NewWatcher[config](time.Second, env.WithSource(base))
+	require.ErrorIs(t, err, env.ErrFileNotFound)
+
+	_, err = env.NewWatcher[config](0, env.WithSource(base))
+	require.ErrorIs(t, err, env.ErrWatchInterval)
+	_, err = env.NewWatcher[config](-time.Second, env.WithSource(base))
+	require.ErrorIs(t, err, env.ErrWatchInterval)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "password"), []byte("v1"), 0o600))
+	w, err := env.NewWatcher[config](time.Second, env.WithSource(base))
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "password")))
+	require.ErrorIs(t, w.Reload(), env.ErrFileNotFound)
+	require.Equal(t, "v1", w.Load().Password)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "password"), []byte("v2"), 0o600))
+	require.NoError(t, w.Reload())
+	require.Equal(t, "v2", w.Load().Password)
+}
+
+func TestWatcher_DotenvError(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Port int `env:"PORT"`
+	}
+
+	dotenvPath := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(dotenvPath, []byte("PORT=80"), 0o600))
+	src, err := env.DotenvSource(dotenvPath, env.MapSource(nil, nil), env.DotenvFill)
+	require.NoError(t, err)
+
+	w, err := env.NewWatcher[config](time.Millisecond, env.WithSource(src))
+	require.NoError(t, err)
+
+	var errs atomic.Int32
+	w.OnError(func(error) { errs.Add(1) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	defer func() {
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	}()
+
+	// an invalid dotenv file is reported once, not on every poll
+	require.NoError(t, os.WriteFile(dotenvPath, []byte("PORT=\"unterminated"), 0o600))
+	require.Eventually(t, func() bool { return errs.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	require.EqualValues(t, 1, errs.Load())
+	require.Equal(t, 80, w.Load().Port)
+
+	require.NoError(t, os.WriteFile(dotenvPath, []byte("PORT=443"), 0o600))
+	require.Eventually(t, func() bool { return w.Load().Port == 443 }, time.Second, time.Millisecond)
+}